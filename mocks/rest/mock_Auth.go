@@ -0,0 +1,89 @@
+// Code generated by mockery; DO NOT EDIT.
+// github.com/vektra/mockery
+// template: testify
+
+package rest
+
+import (
+	"net/http"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// NewMockAuth creates a new instance of MockAuth. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockAuth(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockAuth {
+	mock := &MockAuth{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockAuth is an autogenerated mock type for the Auth type
+type MockAuth struct {
+	mock.Mock
+}
+
+type MockAuth_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockAuth) EXPECT() *MockAuth_Expecter {
+	return &MockAuth_Expecter{mock: &_m.Mock}
+}
+
+// Authenticate provides a mock function for the type MockAuth
+func (_mock *MockAuth) Authenticate(request *http.Request) error {
+	ret := _mock.Called(request)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Authenticate")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(*http.Request) error); ok {
+		r0 = returnFunc(request)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockAuth_Authenticate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Authenticate'
+type MockAuth_Authenticate_Call struct {
+	*mock.Call
+}
+
+// Authenticate is a helper method to define mock.On call
+//   - request *http.Request
+func (_e *MockAuth_Expecter) Authenticate(request interface{}) *MockAuth_Authenticate_Call {
+	return &MockAuth_Authenticate_Call{Call: _e.mock.On("Authenticate", request)}
+}
+
+func (_c *MockAuth_Authenticate_Call) Run(run func(request *http.Request)) *MockAuth_Authenticate_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 *http.Request
+		if args[0] != nil {
+			arg0 = args[0].(*http.Request)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockAuth_Authenticate_Call) Return(err error) *MockAuth_Authenticate_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockAuth_Authenticate_Call) RunAndReturn(run func(request *http.Request) error) *MockAuth_Authenticate_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -0,0 +1,140 @@
+// Code generated by mockery; DO NOT EDIT.
+// github.com/vektra/mockery
+// template: testify
+
+package rest
+
+import (
+	"net/http"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// NewMockAuthRenewer creates a new instance of MockAuthRenewer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockAuthRenewer(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockAuthRenewer {
+	mock := &MockAuthRenewer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockAuthRenewer is an autogenerated mock type for the AuthRenewer type
+type MockAuthRenewer struct {
+	mock.Mock
+}
+
+type MockAuthRenewer_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockAuthRenewer) EXPECT() *MockAuthRenewer_Expecter {
+	return &MockAuthRenewer_Expecter{mock: &_m.Mock}
+}
+
+// Authenticate provides a mock function for the type MockAuthRenewer
+func (_mock *MockAuthRenewer) Authenticate(request *http.Request) error {
+	ret := _mock.Called(request)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Authenticate")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(*http.Request) error); ok {
+		r0 = returnFunc(request)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockAuthRenewer_Authenticate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Authenticate'
+type MockAuthRenewer_Authenticate_Call struct {
+	*mock.Call
+}
+
+// Authenticate is a helper method to define mock.On call
+//   - request *http.Request
+func (_e *MockAuthRenewer_Expecter) Authenticate(request interface{}) *MockAuthRenewer_Authenticate_Call {
+	return &MockAuthRenewer_Authenticate_Call{Call: _e.mock.On("Authenticate", request)}
+}
+
+func (_c *MockAuthRenewer_Authenticate_Call) Run(run func(request *http.Request)) *MockAuthRenewer_Authenticate_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 *http.Request
+		if args[0] != nil {
+			arg0 = args[0].(*http.Request)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockAuthRenewer_Authenticate_Call) Return(err error) *MockAuthRenewer_Authenticate_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockAuthRenewer_Authenticate_Call) RunAndReturn(run func(request *http.Request) error) *MockAuthRenewer_Authenticate_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Renew provides a mock function for the type MockAuthRenewer
+func (_mock *MockAuthRenewer) Renew(request *http.Request) error {
+	ret := _mock.Called(request)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Renew")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(*http.Request) error); ok {
+		r0 = returnFunc(request)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockAuthRenewer_Renew_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Renew'
+type MockAuthRenewer_Renew_Call struct {
+	*mock.Call
+}
+
+// Renew is a helper method to define mock.On call
+//   - request *http.Request
+func (_e *MockAuthRenewer_Expecter) Renew(request interface{}) *MockAuthRenewer_Renew_Call {
+	return &MockAuthRenewer_Renew_Call{Call: _e.mock.On("Renew", request)}
+}
+
+func (_c *MockAuthRenewer_Renew_Call) Run(run func(request *http.Request)) *MockAuthRenewer_Renew_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 *http.Request
+		if args[0] != nil {
+			arg0 = args[0].(*http.Request)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockAuthRenewer_Renew_Call) Return(err error) *MockAuthRenewer_Renew_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockAuthRenewer_Renew_Call) RunAndReturn(run func(request *http.Request) error) *MockAuthRenewer_Renew_Call {
+	_c.Call.Return(run)
+	return _c
+}
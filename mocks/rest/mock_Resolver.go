@@ -0,0 +1,107 @@
+// Code generated by mockery; DO NOT EDIT.
+// github.com/vektra/mockery
+// template: testify
+
+package rest
+
+import (
+	"context"
+	"net"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// NewMockResolver creates a new instance of MockResolver. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockResolver(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockResolver {
+	mock := &MockResolver{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockResolver is an autogenerated mock type for the Resolver type
+type MockResolver struct {
+	mock.Mock
+}
+
+type MockResolver_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockResolver) EXPECT() *MockResolver_Expecter {
+	return &MockResolver_Expecter{mock: &_m.Mock}
+}
+
+// LookupIPAddr provides a mock function for the type MockResolver
+func (_mock *MockResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	ret := _mock.Called(ctx, host)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LookupIPAddr")
+	}
+
+	var r0 []net.IPAddr
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]net.IPAddr, error)); ok {
+		return returnFunc(ctx, host)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []net.IPAddr); ok {
+		r0 = returnFunc(ctx, host)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]net.IPAddr)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, host)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockResolver_LookupIPAddr_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LookupIPAddr'
+type MockResolver_LookupIPAddr_Call struct {
+	*mock.Call
+}
+
+// LookupIPAddr is a helper method to define mock.On call
+//   - ctx context.Context
+//   - host string
+func (_e *MockResolver_Expecter) LookupIPAddr(ctx interface{}, host interface{}) *MockResolver_LookupIPAddr_Call {
+	return &MockResolver_LookupIPAddr_Call{Call: _e.mock.On("LookupIPAddr", ctx, host)}
+}
+
+func (_c *MockResolver_LookupIPAddr_Call) Run(run func(ctx context.Context, host string)) *MockResolver_LookupIPAddr_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockResolver_LookupIPAddr_Call) Return(iPAddrs []net.IPAddr, err error) *MockResolver_LookupIPAddr_Call {
+	_c.Call.Return(iPAddrs, err)
+	return _c
+}
+
+func (_c *MockResolver_LookupIPAddr_Call) RunAndReturn(run func(ctx context.Context, host string) ([]net.IPAddr, error)) *MockResolver_LookupIPAddr_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -0,0 +1,95 @@
+// Code generated by mockery; DO NOT EDIT.
+// github.com/vektra/mockery
+// template: testify
+
+package rest
+
+import (
+	"net/http"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// NewMockSigner creates a new instance of MockSigner. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockSigner(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockSigner {
+	mock := &MockSigner{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockSigner is an autogenerated mock type for the Signer type
+type MockSigner struct {
+	mock.Mock
+}
+
+type MockSigner_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockSigner) EXPECT() *MockSigner_Expecter {
+	return &MockSigner_Expecter{mock: &_m.Mock}
+}
+
+// Sign provides a mock function for the type MockSigner
+func (_mock *MockSigner) Sign(request *http.Request, body []byte) error {
+	ret := _mock.Called(request, body)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Sign")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(*http.Request, []byte) error); ok {
+		r0 = returnFunc(request, body)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockSigner_Sign_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Sign'
+type MockSigner_Sign_Call struct {
+	*mock.Call
+}
+
+// Sign is a helper method to define mock.On call
+//   - request *http.Request
+//   - body []byte
+func (_e *MockSigner_Expecter) Sign(request interface{}, body interface{}) *MockSigner_Sign_Call {
+	return &MockSigner_Sign_Call{Call: _e.mock.On("Sign", request, body)}
+}
+
+func (_c *MockSigner_Sign_Call) Run(run func(request *http.Request, body []byte)) *MockSigner_Sign_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 *http.Request
+		if args[0] != nil {
+			arg0 = args[0].(*http.Request)
+		}
+		var arg1 []byte
+		if args[1] != nil {
+			arg1 = args[1].([]byte)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockSigner_Sign_Call) Return(err error) *MockSigner_Sign_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockSigner_Sign_Call) RunAndReturn(run func(request *http.Request, body []byte) error) *MockSigner_Sign_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -82,6 +82,27 @@ func setup() {
 	tmux.HandleFunc("/header", withHeader)
 }
 
+// newServer starts a test server for the handler, closed when the test ends. The
+// configure functions run before it starts, and a server with a TLS config starts with
+// TLS.
+func newServer(t *testing.T, handler http.Handler, configure ...func(*httptest.Server)) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewUnstartedServer(handler)
+	for _, fn := range configure {
+		fn(srv)
+	}
+
+	if srv.TLS != nil {
+		srv.StartTLS()
+	} else {
+		srv.Start()
+	}
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
 func withHeader(writer http.ResponseWriter, req *http.Request) {
 	if req.Method == http.MethodGet {
 		if h := req.Header.Get("X-Test"); h == "test" {
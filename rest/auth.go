@@ -0,0 +1,287 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Auth adds credentials to outgoing requests. Set it in Client.Auth to authenticate
+// every request of the client, together with OAuth when both are configured.
+//
+// Credentials are added to a copy of each request, so they do not appear in
+// Response.Debug, and they are never sent to a redirect that crosses to another host.
+//
+// Example usage:
+//
+//	client := &rest.Client{
+//	    BaseURL: "https://api.example.com",
+//	    Auth: rest.ChainAuth(
+//	        rest.BearerTokenFunc(func(ctx context.Context) (string, error) {
+//	            return vault.Token(ctx)
+//	        }),
+//	        rest.APIKeyHeader("X-Api-Key", apiKey),
+//	    ),
+//	}
+type Auth interface {
+	// Authenticate adds the credentials to the request.
+	Authenticate(request *http.Request) error
+}
+
+// AuthRenewer is an Auth whose credentials can be renewed. When a request is rejected
+// with 401 Unauthorized, Renew is called with the rejected request and the request is
+// retried once with fresh credentials, provided its body can be replayed.
+type AuthRenewer interface {
+	Auth
+	// Renew discards the credentials of the rejected request.
+	Renew(request *http.Request) error
+}
+
+// AuthFunc adapts a function to Auth.
+type AuthFunc func(request *http.Request) error
+
+// Authenticate calls f(request).
+func (f AuthFunc) Authenticate(request *http.Request) error {
+	return f(request)
+}
+
+// BearerToken returns an Auth sending a static bearer token in the Authorization header.
+func BearerToken(token string) Auth {
+	return AuthFunc(func(request *http.Request) error {
+		request.Header.Set(AuthorizationHeader, "Bearer "+token)
+		return nil
+	})
+}
+
+// BearerTokenFunc returns an Auth sending the bearer token returned by fn, for rotating
+// tokens. fn is called for every request and again when retrying after 401 Unauthorized,
+// so it must cache the token itself if fetching it is expensive.
+func BearerTokenFunc(fn func(ctx context.Context) (string, error)) Auth {
+	return bearerTokenFunc(fn)
+}
+
+// bearerTokenFunc is the Auth returned by BearerTokenFunc.
+type bearerTokenFunc func(ctx context.Context) (string, error)
+
+// Authenticate sets the Authorization header with the token returned by the function.
+func (f bearerTokenFunc) Authenticate(request *http.Request) error {
+	token, err := f(request.Context())
+	if err != nil {
+		return err
+	}
+	request.Header.Set(AuthorizationHeader, "Bearer "+token)
+
+	return nil
+}
+
+// Renew does nothing, the token is fetched again for the retry.
+func (f bearerTokenFunc) Renew(*http.Request) error {
+	return nil
+}
+
+// APIKeyHeader returns an Auth sending the API key in the given header.
+func APIKeyHeader(name, key string) Auth {
+	return AuthFunc(func(request *http.Request) error {
+		request.Header.Set(name, key)
+		return nil
+	})
+}
+
+// APIKeyQuery returns an Auth sending the API key in the given query parameter.
+func APIKeyQuery(name, key string) Auth {
+	return AuthFunc(func(request *http.Request) error {
+		query := request.URL.Query()
+		query.Set(name, key)
+		request.URL.RawQuery = normalizeQuery(query)
+		return nil
+	})
+}
+
+// ChainAuth returns an Auth applying every provider in order. It renews every provider
+// implementing AuthRenewer.
+func ChainAuth(providers ...Auth) Auth {
+	return chainAuth(providers)
+}
+
+// chainAuth is the Auth returned by ChainAuth.
+type chainAuth []Auth
+
+// Authenticate applies every provider in order.
+func (r chainAuth) Authenticate(request *http.Request) error {
+	for _, provider := range r {
+		if err := provider.Authenticate(request); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Renew renews every provider implementing AuthRenewer.
+func (r chainAuth) Renew(request *http.Request) error {
+	var errs []error
+	for _, provider := range r {
+		if renewer, ok := provider.(AuthRenewer); ok {
+			errs = append(errs, renewer.Renew(request))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// challenged passes the 401 response to every provider implementing challenger.
+func (r chainAuth) challenged(response *http.Response) error {
+	var errs []error
+	for _, provider := range r {
+		if c, ok := provider.(challenger); ok {
+			errs = append(errs, c.challenged(response))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// renewable reports whether any provider of the chain can be renewed.
+func (r chainAuth) renewable() bool {
+	for _, provider := range r {
+		if _, ok := provider.(AuthRenewer); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Authenticate sets the Basic Authorization header, so BasicAuth can be chained
+// with other providers.
+func (r *BasicAuth) Authenticate(request *http.Request) error {
+	request.SetBasicAuth(r.Username, r.Password)
+	return nil
+}
+
+// challenger is a provider answering the WWW-Authenticate challenge of a 401 response,
+// such as DigestAuth.
+type challenger interface {
+	challenged(response *http.Response) error
+}
+
+// authTransport authenticates each request with an Auth provider and, for renewable
+// providers, retries once on 401 Unauthorized.
+type authTransport struct {
+	Transport http.RoundTripper
+	auth      Auth
+}
+
+// RoundTrip authenticates a copy of the request and sends it. The response keeps the
+// original request, without credentials.
+func (r *authTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	// Never leak credentials to another host
+	if crossHostRedirect(request) {
+		return r.Transport.RoundTrip(request)
+	}
+
+	authorized, err := r.authenticate(request)
+	if err != nil {
+		closeBody(request)
+		return nil, err
+	}
+
+	response, err := r.Transport.RoundTrip(authorized)
+	if err != nil || response.StatusCode != http.StatusUnauthorized || !r.renewable() {
+		return withRequest(response, request), err
+	}
+
+	retry, ok := rewind(request)
+	if !ok {
+		return withRequest(response, request), nil
+	}
+
+	if c, ok := r.auth.(challenger); ok {
+		if err = c.challenged(response); err != nil {
+			return withRequest(response, request), nil
+		}
+	}
+
+	if err = r.auth.(AuthRenewer).Renew(authorized); err != nil {
+		return withRequest(response, request), nil
+	}
+
+	if authorized, err = r.authenticate(retry); err != nil {
+		return withRequest(response, request), nil
+	}
+	_ = response.Body.Close()
+
+	response, err = r.Transport.RoundTrip(authorized)
+
+	return withRequest(response, request), err
+}
+
+// authenticate returns a copy of the request with the credentials of the provider.
+func (r *authTransport) authenticate(request *http.Request) (*http.Request, error) {
+	authorized := request.Clone(request.Context())
+	if err := r.auth.Authenticate(authorized); err != nil {
+		return nil, err
+	}
+
+	return authorized, nil
+}
+
+// renewable reports whether the provider can be renewed after 401 Unauthorized.
+func (r *authTransport) renewable() bool {
+	if chain, ok := r.auth.(chainAuth); ok {
+		return chain.renewable()
+	}
+
+	_, ok := r.auth.(AuthRenewer)
+	return ok
+}
+
+// withRequest sets the original request, without credentials, in the response.
+func withRequest(response *http.Response, request *http.Request) *http.Response {
+	if response != nil {
+		response.Request = request
+	}
+
+	return response
+}
+
+// closeBody closes the request body, as a RoundTripper must even on error.
+func closeBody(request *http.Request) {
+	if request.Body != nil {
+		_ = request.Body.Close()
+	}
+}
+
+// crossHostRedirect reports whether the request follows a redirect to a host other
+// than the one of the original request.
+func crossHostRedirect(request *http.Request) bool {
+	original := request
+	for original.Response != nil && original.Response.Request != nil {
+		original = original.Response.Request
+	}
+
+	return original != request && !strings.EqualFold(original.URL.Host, request.URL.Host)
+}
+
+// rewind returns a copy of the request with a fresh body, for sending it again.
+// It reports false when the body cannot be replayed.
+func rewind(request *http.Request) (*http.Request, bool) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return request, true
+	}
+
+	if request.GetBody == nil {
+		return nil, false
+	}
+
+	body, err := request.GetBody()
+	if err != nil {
+		return nil, false
+	}
+
+	request = request.Clone(request.Context())
+	request.Body = body
+
+	return request, true
+}
@@ -0,0 +1,170 @@
+package rest_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arielsrv/go-restclient/rest"
+)
+
+func TestAuth_Chain(t *testing.T) {
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer static-token", r.Header.Get("Authorization"))
+		assert.Equal(t, "header-key", r.Header.Get("X-Api-Key"))
+		assert.Equal(t, "query-key", r.URL.Query().Get("api_key"))
+		assert.Equal(t, "1", r.URL.Query().Get("page"))
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	client := &rest.Client{
+		BaseURL: srv.URL,
+		Auth: rest.ChainAuth(
+			rest.BearerToken("static-token"),
+			rest.APIKeyHeader("X-Api-Key", "header-key"),
+			rest.APIKeyQuery("api_key", "query-key"),
+		),
+	}
+
+	response := client.GetWithContext(t.Context(), "/users?page=1")
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	debug := response.Debug()
+	assert.NotContains(t, debug, "static-token")
+	assert.NotContains(t, debug, "header-key")
+	assert.NotContains(t, debug, "query-key")
+}
+
+func TestAuth_BearerTokenFunc_Renew(t *testing.T) {
+	var calls atomic.Int32
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	var fetched atomic.Int32
+	client := &rest.Client{
+		BaseURL:     srv.URL,
+		ContentType: rest.JSON,
+		Auth: rest.BearerTokenFunc(func(context.Context) (string, error) {
+			return fmt.Sprintf("token-%d", fetched.Add(1)), nil
+		}),
+	}
+
+	response := client.PostWithContext(t.Context(), "/users", User{Name: "Maria"})
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, int32(2), fetched.Load())
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestAuth_Static_NoRetry(t *testing.T) {
+	var calls atomic.Int32
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+
+	client := &rest.Client{BaseURL: srv.URL, Auth: rest.APIKeyHeader("X-Api-Key", "key")}
+
+	response := client.GetWithContext(t.Context(), "/")
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestAuth_Err(t *testing.T) {
+	expected := errors.New("vault unavailable")
+	client := &rest.Client{
+		BaseURL: server.URL,
+		Auth: rest.BearerTokenFunc(func(context.Context) (string, error) {
+			return "", expected
+		}),
+	}
+
+	response := client.GetWithContext(t.Context(), "/user")
+	require.ErrorIs(t, response.Err, expected)
+}
+
+func TestAuth_CrossHostRedirect(t *testing.T) {
+	other := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Authorization"))
+		assert.Empty(t, r.Header.Get("X-Api-Key"))
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		if r.URL.Path == "/same" {
+			http.Redirect(w, r, "/final", http.StatusFound)
+			return
+		}
+		if r.URL.Path == "/other" {
+			http.Redirect(w, r, other.URL+"/final", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	client := &rest.Client{
+		BaseURL:        srv.URL,
+		FollowRedirect: true,
+		Auth:           rest.ChainAuth(rest.BearerToken("token"), rest.APIKeyHeader("X-Api-Key", "key")),
+	}
+
+	response := client.GetWithContext(t.Context(), "/same")
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	response = client.GetWithContext(t.Context(), "/other")
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestAuth_BasicAuthChained(t *testing.T) {
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "user", username)
+		assert.Equal(t, "secret", password)
+		assert.Equal(t, "header-key", r.Header.Get("X-Api-Key"))
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	client := &rest.Client{
+		BaseURL:   srv.URL,
+		BasicAuth: &rest.BasicAuth{Username: "user", Password: "secret"},
+		Auth:      rest.APIKeyHeader("X-Api-Key", "header-key"),
+	}
+
+	response := client.GetWithContext(t.Context(), "/users")
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestDebug_BasicAuthRedacted(t *testing.T) {
+	client := &rest.Client{
+		BaseURL:   server.URL,
+		BasicAuth: &rest.BasicAuth{Username: "user", Password: "secret"},
+	}
+
+	response := client.GetWithContext(t.Context(), "/user")
+	require.NoError(t, response.Err)
+
+	// Credentials are set by the transport, on a clone of the dumped request
+	debug := response.Debug()
+	assert.NotContains(t, debug, "Authorization")
+	assert.NotContains(t, debug, base64.StdEncoding.EncodeToString([]byte("user:secret")))
+}
@@ -0,0 +1,243 @@
+package rest
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Passive health checking defaults.
+const (
+	// DefaultMaxFailures is the number of consecutive failures after which an endpoint
+	// is ejected by default.
+	DefaultMaxFailures = 5
+
+	// DefaultEjectionTime is how long an ejected endpoint receives no requests by default.
+	DefaultEjectionTime = 30 * time.Second
+)
+
+// BalanceStrategy selects the endpoint of Client.BaseURLs each request is sent to.
+type BalanceStrategy int
+
+const (
+	// RoundRobin sends requests to every endpoint in turn.
+	RoundRobin BalanceStrategy = iota
+
+	// LeastRequests sends every request to the endpoint with the fewest outstanding
+	// requests, in turn among equals.
+	LeastRequests
+
+	// Weighted sends requests to every endpoint in turn, in proportion to its weight.
+	Weighted
+)
+
+// LoadBalancer configures how the requests of a Client are spread across its BaseURLs.
+//
+// Endpoints are tracked passively: an endpoint failing MaxFailures requests in a row,
+// with a transport error or a 5xx status, is ejected for EjectionTime and then receives
+// requests again. A reinstated endpoint failing once more is ejected again right away.
+// When every endpoint is ejected, requests are spread across all of them.
+//
+// Example usage:
+//
+//	client := &rest.Client{
+//	    BaseURLs: []string{"http://10.0.0.1:8080", "http://10.0.0.2:8080", "http://10.0.0.3:8080"},
+//	    LoadBalancer: &rest.LoadBalancer{
+//	        Strategy:     rest.Weighted,
+//	        Weights:      map[string]int{"http://10.0.0.3:8080": 2},
+//	        MaxFailures:  3,
+//	        EjectionTime: 10 * time.Second,
+//	    },
+//	}
+type LoadBalancer struct {
+	// Weights are the weights of the endpoints of the Weighted strategy, by base URL.
+	// Endpoints without a weight have weight 1.
+	Weights map[string]int
+	// Strategy selects the endpoint of each request, RoundRobin by default.
+	Strategy BalanceStrategy
+	// MaxFailures is the number of consecutive failures after which an endpoint is
+	// ejected, DefaultMaxFailures by default. Negative disables ejection.
+	MaxFailures int
+	// EjectionTime is how long an ejected endpoint receives no requests,
+	// DefaultEjectionTime by default.
+	EjectionTime time.Duration
+}
+
+// balancer spreads the requests of a client across its endpoints.
+type balancer struct {
+	config    LoadBalancer
+	endpoints []*endpoint
+	next      int
+	mtx       sync.Mutex
+}
+
+// endpoint is a base URL of a client with its load and health.
+type endpoint struct {
+	balancer     *balancer
+	ejectedUntil time.Time
+	baseURL      string
+	weight       int
+	current      int
+	outstanding  int
+	failures     int
+}
+
+// endpointKey carries the base URL of the endpoint of a request in its context.
+type endpointKey struct{}
+
+// pickEndpoint returns the endpoint of the next request, or nil when the client has no
+// BaseURLs. The endpoint must be released once the request is done.
+func (r *Client) pickEndpoint() *endpoint {
+	return r.pickEndpointExcept(nil)
+}
+
+// pickEndpointExcept is pickEndpoint avoiding the given endpoint, e.g. the one a hedged
+// request was sent to, unless there is no other.
+func (r *Client) pickEndpointExcept(exclude *endpoint) *endpoint {
+	if len(r.BaseURLs) == 0 {
+		return nil
+	}
+
+	r.balancerOnce.Do(func() {
+		r.balancer = &balancer{}
+		if r.LoadBalancer != nil {
+			r.balancer.config = *r.LoadBalancer
+		}
+
+		for _, baseURL := range r.BaseURLs {
+			r.balancer.endpoints = append(r.balancer.endpoints, &endpoint{
+				balancer: r.balancer,
+				baseURL:  baseURL,
+				weight:   max(cmp.Or(r.balancer.config.Weights[baseURL], 1), 1),
+			})
+		}
+	})
+
+	return r.balancer.pick(exclude)
+}
+
+// pick selects a healthy endpoint, other than exclude when possible, or any endpoint
+// when every one is ejected.
+func (r *balancer) pick(exclude *endpoint) *endpoint {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	candidates := r.healthy(exclude)
+	if len(candidates) == 0 && exclude != nil {
+		candidates = r.healthy(nil)
+	}
+	if len(candidates) == 0 {
+		candidates = r.endpoints
+	}
+
+	var chosen *endpoint
+	switch r.config.Strategy {
+	case LeastRequests:
+		for i := range candidates {
+			e := candidates[(r.next+i)%len(candidates)]
+			if chosen == nil || e.outstanding < chosen.outstanding {
+				chosen = e
+			}
+		}
+		r.next++
+	case Weighted:
+		// Smooth weighted round-robin: every endpoint gains its weight and the chosen one
+		// gives back the total, so picks are interleaved rather than bursty
+		total := 0
+		for _, e := range candidates {
+			e.current += e.weight
+			total += e.weight
+			if chosen == nil || e.current > chosen.current {
+				chosen = e
+			}
+		}
+		chosen.current -= total
+	default:
+		chosen = candidates[r.next%len(candidates)]
+		r.next++
+	}
+
+	chosen.outstanding++
+
+	return chosen
+}
+
+// healthy returns the endpoints not ejected, other than exclude.
+func (r *balancer) healthy(exclude *endpoint) []*endpoint {
+	now := time.Now()
+	candidates := make([]*endpoint, 0, len(r.endpoints))
+	for _, e := range r.endpoints {
+		if e != exclude && !now.Before(e.ejectedUntil) {
+			candidates = append(candidates, e)
+		}
+	}
+
+	return candidates
+}
+
+// base returns the base URL of the endpoint, or the BaseURL of the client when there is
+// no endpoint.
+func (r *endpoint) base(client *Client) string {
+	if r == nil {
+		return client.baseURL()
+	}
+
+	return r.baseURL
+}
+
+// cacheURL returns the URL caching the resource of a request. Requests to BaseURLs are
+// resolved against the first one, so that every endpoint shares the cached responses
+// and ETags of a resource.
+func (r *Client) cacheURL(target *endpoint, requestURL string, apiURL string, options *requestOptions) (string, error) {
+	if target == nil {
+		return r.socketURL(requestURL), nil
+	}
+
+	return r.resolveURL(r.BaseURLs[0], apiURL, options)
+}
+
+// withContext records the endpoint in the request context, for tracing.
+func (r *endpoint) withContext(ctx context.Context) context.Context {
+	if r == nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, endpointKey{}, r.baseURL)
+}
+
+// observe tracks the health of the endpoint from the outcome of a request. Requests
+// cancelled by the caller say nothing about the endpoint and are ignored.
+func (r *endpoint) observe(ctx context.Context, response *http.Response, err error) {
+	if r == nil || errors.Is(context.Cause(ctx), context.Canceled) {
+		return
+	}
+
+	r.balancer.mtx.Lock()
+	defer r.balancer.mtx.Unlock()
+
+	if err == nil && response.StatusCode < http.StatusInternalServerError {
+		r.failures = 0
+		return
+	}
+
+	r.failures++
+	maxFailures := cmp.Or(r.balancer.config.MaxFailures, DefaultMaxFailures)
+	if maxFailures > 0 && r.failures >= maxFailures {
+		r.ejectedUntil = time.Now().Add(cmp.Or(r.balancer.config.EjectionTime, DefaultEjectionTime))
+	}
+}
+
+// release marks the request sent to the endpoint as done.
+func (r *endpoint) release() {
+	if r == nil {
+		return
+	}
+
+	r.balancer.mtx.Lock()
+	defer r.balancer.mtx.Unlock()
+
+	r.outstanding--
+}
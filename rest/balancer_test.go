@@ -0,0 +1,237 @@
+package rest_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arielsrv/go-restclient/rest"
+)
+
+// replica is a server of a load balanced service, failing with 503 Service Unavailable
+// while unhealthy.
+type replica struct {
+	*httptest.Server
+	unhealthy atomic.Bool
+	hits      atomic.Int32
+}
+
+func newReplicas(t *testing.T, n int, handler http.HandlerFunc) ([]*replica, []string) {
+	t.Helper()
+
+	replicas := make([]*replica, n)
+	urls := make([]string, n)
+	for i := range replicas {
+		rep := new(replica)
+		rep.Server = newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rep.hits.Add(1)
+			if rep.unhealthy.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			if handler != nil {
+				handler(w, r)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		replicas[i], urls[i] = rep, rep.URL
+	}
+
+	return replicas, urls
+}
+
+func TestLoadBalancer_RoundRobin(t *testing.T) {
+	replicas, urls := newReplicas(t, 3, nil)
+	client := &rest.Client{BaseURLs: urls}
+
+	var endpoints []string
+	for range 6 {
+		response := client.GetWithContext(t.Context(), "/user")
+		require.NoError(t, response.Err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		endpoints = append(endpoints, response.Endpoint)
+	}
+
+	assert.Equal(t, append(urls, urls...), endpoints)
+	for _, rep := range replicas {
+		assert.Equal(t, int32(2), rep.hits.Load())
+	}
+}
+
+func TestLoadBalancer_Weighted(t *testing.T) {
+	replicas, urls := newReplicas(t, 2, nil)
+	client := &rest.Client{
+		BaseURLs: urls,
+		LoadBalancer: &rest.LoadBalancer{
+			Strategy: rest.Weighted,
+			Weights:  map[string]int{urls[1]: 3},
+		},
+	}
+
+	var endpoints []string
+	for range 8 {
+		response := client.GetWithContext(t.Context(), "/user")
+		require.NoError(t, response.Err)
+		endpoints = append(endpoints, response.Endpoint)
+	}
+
+	// Picks are interleaved rather than bursty
+	assert.Equal(t, []string{urls[1], urls[0], urls[1], urls[1], urls[1], urls[0], urls[1], urls[1]}, endpoints)
+	assert.Equal(t, int32(2), replicas[0].hits.Load())
+	assert.Equal(t, int32(6), replicas[1].hits.Load())
+}
+
+func TestLoadBalancer_LeastRequests(t *testing.T) {
+	release := make(chan struct{})
+	var slow sync.WaitGroup
+	slow.Add(2)
+	replicas, urls := newReplicas(t, 3, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			slow.Done()
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	client := &rest.Client{
+		BaseURLs:     urls,
+		Timeout:      5 * time.Second,
+		LoadBalancer: &rest.LoadBalancer{Strategy: rest.LeastRequests},
+	}
+
+	// Two slow requests keep the first two replicas busy
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Go(func() {
+			response := client.GetWithContext(t.Context(), "/slow")
+			assert.NoError(t, response.Err)
+		})
+	}
+	slow.Wait()
+
+	for range 3 {
+		response := client.GetWithContext(t.Context(), "/user")
+		require.NoError(t, response.Err)
+		assert.Equal(t, urls[2], response.Endpoint)
+	}
+
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), replicas[0].hits.Load())
+	assert.Equal(t, int32(1), replicas[1].hits.Load())
+	assert.Equal(t, int32(3), replicas[2].hits.Load())
+}
+
+func TestLoadBalancer_Ejection(t *testing.T) {
+	replicas, urls := newReplicas(t, 2, nil)
+	replicas[0].unhealthy.Store(true)
+
+	client := &rest.Client{
+		BaseURLs: urls,
+		LoadBalancer: &rest.LoadBalancer{
+			MaxFailures:  2,
+			EjectionTime: 100 * time.Millisecond,
+		},
+	}
+
+	// The first replica fails twice in a row and is ejected
+	for range 10 {
+		response := client.GetWithContext(t.Context(), "/user")
+		require.NoError(t, response.Err)
+	}
+	assert.Equal(t, int32(2), replicas[0].hits.Load())
+	assert.Equal(t, int32(8), replicas[1].hits.Load())
+
+	// Once reinstated, a single failure ejects it again
+	time.Sleep(150 * time.Millisecond)
+	for range 4 {
+		response := client.GetWithContext(t.Context(), "/user")
+		require.NoError(t, response.Err)
+	}
+	assert.Equal(t, int32(3), replicas[0].hits.Load())
+	assert.Equal(t, int32(11), replicas[1].hits.Load())
+
+	// Once healthy, it receives requests again
+	replicas[0].unhealthy.Store(false)
+	time.Sleep(150 * time.Millisecond)
+	for range 4 {
+		response := client.GetWithContext(t.Context(), "/user")
+		require.NoError(t, response.Err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+	}
+	assert.Equal(t, int32(5), replicas[0].hits.Load())
+	assert.Equal(t, int32(13), replicas[1].hits.Load())
+}
+
+func TestLoadBalancer_AllEjected(t *testing.T) {
+	replicas, urls := newReplicas(t, 2, nil)
+	replicas[0].Close()
+	replicas[1].unhealthy.Store(true)
+
+	client := &rest.Client{
+		BaseURLs:     urls,
+		LoadBalancer: &rest.LoadBalancer{MaxFailures: 1},
+	}
+
+	for range 4 {
+		client.GetWithContext(t.Context(), "/user")
+	}
+
+	// Requests are still spread across every endpoint
+	assert.Equal(t, int32(2), replicas[1].hits.Load())
+
+	response := client.GetWithContext(t.Context(), "/user")
+	require.Error(t, response.Err)
+	assert.Equal(t, urls[0], response.Endpoint)
+}
+
+func TestLoadBalancer_Cache(t *testing.T) {
+	replicas, urls := newReplicas(t, 2, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			if r.Header.Get(rest.IfMatchHeader) != `"v1"` {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set(rest.ETagHeader, `"v1"`)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// The resource is cached by its URL on the first endpoint
+	single := &rest.Client{BaseURL: urls[0], EnableCache: true}
+	assert.Eventually(t, func() bool {
+		return single.GetWithContext(t.Context(), "/balanced/cache").Cached()
+	}, time.Second, 10*time.Millisecond)
+
+	client := &rest.Client{BaseURLs: urls, EnableCache: true}
+	for range 3 {
+		response := client.GetWithContext(t.Context(), "/balanced/cache")
+		require.NoError(t, response.Err)
+		assert.True(t, response.Cached())
+	}
+	assert.Equal(t, int32(0), replicas[1].hits.Load())
+
+	// Its ETag is matched whichever endpoint the write is sent to
+	response := client.R().IfMatchCached().Delete(t.Context(), "/balanced/cache")
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusNoContent, response.StatusCode)
+	assert.Equal(t, urls[1], response.Endpoint)
+}
+
+func TestLoadBalancer_UnixSocket(t *testing.T) {
+	client := &rest.Client{BaseURLs: []string{server.URL, "unix:///tmp/api.sock"}}
+
+	response := client.GetWithContext(t.Context(), "/user")
+	require.ErrorContains(t, response.Err, "BaseURLs cannot be Unix domain sockets")
+}
@@ -0,0 +1,246 @@
+package rest
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultBulkheadLimit is the maximum number of requests in flight of a Bulkhead by default.
+const DefaultBulkheadLimit = 100
+
+// DefaultBulkheadBackoff is the factor the limit of an AdaptiveLimit is multiplied by on
+// overload by default.
+const DefaultBulkheadBackoff = 0.9
+
+// ErrBulkheadFull is reported in Response.Err when a request is rejected because the
+// bulkhead of the client has no free slot and its queue is full.
+var ErrBulkheadFull = errors.New("bulkhead full: too many requests in flight")
+
+// Bulkhead caps the requests in flight of a Client, so one slow dependency cannot exhaust
+// goroutines and sockets. Requests beyond the limit wait in a queue of up to MaxQueue
+// callers, for up to QueueTimeout; requests finding the queue full fail right away with
+// ErrBulkheadFull, and requests waiting longer than QueueTimeout with a TimeoutError of
+// phase TimeoutPhaseQueue. Responses served from the cache take no slot.
+//
+// The limit can be changed at runtime with SetLimit, or adapted to the observed latency
+// with Adaptive. A Bulkhead can be shared by several clients.
+//
+// Example usage:
+//
+//	client := &rest.Client{
+//	    BaseURL: "https://api.example.com",
+//	    Bulkhead: &rest.Bulkhead{
+//	        MaxConcurrent: 20,
+//	        MaxQueue:      50,
+//	        QueueTimeout:  100 * time.Millisecond,
+//	    },
+//	}
+//
+//	if errors.Is(response.Err, rest.ErrBulkheadFull) {
+//	    // shed load
+//	}
+type Bulkhead struct {
+	// Adaptive adjusts the limit to the observed latency. The limit is fixed by default.
+	Adaptive *AdaptiveLimit
+	waiters  []chan struct{}
+	// lastDecrease is when the adaptive limit was last decreased.
+	lastDecrease time.Time
+	// MaxConcurrent is the initial maximum number of requests in flight,
+	// DefaultBulkheadLimit by default.
+	MaxConcurrent int
+	// MaxQueue is the maximum number of requests waiting for a slot. Zero rejects every
+	// request beyond the limit.
+	MaxQueue int
+	// QueueTimeout is how long a request waits for a slot. Zero waits until the request
+	// context ends.
+	QueueTimeout time.Duration
+	limit        int
+	inflight     int
+	successes    int
+	once         sync.Once
+	mtx          sync.Mutex
+}
+
+// AdaptiveLimit adjusts the limit of a Bulkhead with additive increase and multiplicative
+// decrease (AIMD): a request slower than Latency, or failing, multiplies the limit by
+// Backoff, and the limit grows by one after as many fast requests as the limit.
+type AdaptiveLimit struct {
+	// Latency is the latency above which a request signals an overloaded dependency.
+	// Zero only takes failures as overload signals.
+	Latency time.Duration
+	// MinLimit is the lowest limit, 1 by default.
+	MinLimit int
+	// MaxLimit is the highest limit, Bulkhead.MaxConcurrent by default.
+	MaxLimit int
+	// Backoff is the factor the limit is multiplied by on overload,
+	// DefaultBulkheadBackoff by default.
+	Backoff float64
+}
+
+// init sets the initial limit. It must be called with the lock held.
+func (r *Bulkhead) init() {
+	r.once.Do(func() {
+		if r.limit == 0 {
+			r.limit = cmp.Or(r.MaxConcurrent, DefaultBulkheadLimit)
+		}
+	})
+}
+
+// Limit returns the current maximum number of requests in flight.
+func (r *Bulkhead) Limit() int {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.init()
+
+	return r.limit
+}
+
+// SetLimit changes the maximum number of requests in flight. Requests in flight beyond
+// a lower limit are not interrupted; waiting requests are admitted under a higher one.
+func (r *Bulkhead) SetLimit(limit int) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.init()
+	r.limit = max(limit, 1)
+	r.admit()
+}
+
+// InFlight returns the number of requests holding a slot.
+func (r *Bulkhead) InFlight() int {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	return r.inflight
+}
+
+// bulkheadSlot is a slot of a Bulkhead taken by a request.
+type bulkheadSlot struct {
+	bulkhead *Bulkhead
+	start    time.Time
+}
+
+// acquire takes a slot, waiting in the queue when there is none. The slot must be
+// released once the request is done. It returns a nil slot when the bulkhead is nil.
+func (r *Bulkhead) acquire(ctx context.Context) (*bulkheadSlot, error) {
+	if r == nil {
+		return nil, nil
+	}
+
+	r.mtx.Lock()
+	r.init()
+
+	if r.inflight < r.limit && len(r.waiters) == 0 {
+		r.inflight++
+		r.mtx.Unlock()
+		return &bulkheadSlot{bulkhead: r, start: time.Now()}, nil
+	}
+
+	if len(r.waiters) >= r.MaxQueue {
+		r.mtx.Unlock()
+		return nil, ErrBulkheadFull
+	}
+
+	ready := make(chan struct{})
+	r.waiters = append(r.waiters, ready)
+	r.mtx.Unlock()
+
+	var timeout <-chan time.Time
+	if r.QueueTimeout > 0 {
+		timer := time.NewTimer(r.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var err error
+	select {
+	case <-ready:
+		return &bulkheadSlot{bulkhead: r, start: time.Now()}, nil
+	case <-timeout:
+		err = &TimeoutError{Err: context.DeadlineExceeded, Phase: TimeoutPhaseQueue, After: r.QueueTimeout}
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	// A slot granted meanwhile is handed over to the next waiter
+	if index := slices.Index(r.waiters, ready); index >= 0 {
+		r.waiters = slices.Delete(r.waiters, index, index+1)
+	} else {
+		r.inflight--
+		r.admit()
+	}
+
+	return nil, err
+}
+
+// release frees the slot of a request that is done, adapting the limit to its latency
+// and whether it failed.
+func (r *bulkheadSlot) release(failed bool) {
+	if r == nil {
+		return
+	}
+
+	r.bulkhead.mtx.Lock()
+	defer r.bulkhead.mtx.Unlock()
+
+	if adaptive := r.bulkhead.Adaptive; adaptive != nil {
+		slow := adaptive.Latency > 0 && time.Since(r.start) > adaptive.Latency
+		r.bulkhead.adapt(r.start, failed || slow)
+	}
+	r.bulkhead.inflight--
+	r.bulkhead.admit()
+}
+
+// free frees the slot without adapting the limit, e.g. for a stream, whose duration
+// says nothing about the dependency.
+func (r *bulkheadSlot) free() {
+	if r == nil {
+		return
+	}
+
+	r.bulkhead.mtx.Lock()
+	defer r.bulkhead.mtx.Unlock()
+
+	r.bulkhead.inflight--
+	r.bulkhead.admit()
+}
+
+// adapt adjusts the limit after a request started at start, overloaded or not. Requests
+// started before the last decrease were admitted under the previous limit and are not
+// counted again. It must be called with the lock held.
+func (r *Bulkhead) adapt(start time.Time, overloaded bool) {
+	minLimit := max(r.Adaptive.MinLimit, 1)
+	maxLimit := max(cmp.Or(r.Adaptive.MaxLimit, r.MaxConcurrent, DefaultBulkheadLimit), minLimit)
+
+	switch {
+	case overloaded && start.After(r.lastDecrease):
+		backoff := cmp.Or(r.Adaptive.Backoff, DefaultBulkheadBackoff)
+		r.limit = max(int(float64(r.limit)*backoff), minLimit)
+		r.successes = 0
+		r.lastDecrease = time.Now()
+	case !overloaded:
+		r.successes++
+		if r.successes >= r.limit {
+			r.limit = min(r.limit+1, maxLimit)
+			r.successes = 0
+		}
+	}
+}
+
+// admit grants free slots to the waiting requests, in order. It must be called with the
+// lock held.
+func (r *Bulkhead) admit() {
+	for r.inflight < r.limit && len(r.waiters) > 0 {
+		r.inflight++
+		close(r.waiters[0])
+		r.waiters = r.waiters[1:]
+	}
+}
@@ -0,0 +1,151 @@
+package rest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arielsrv/go-restclient/rest"
+)
+
+// blockingHandler holds every request until release is closed, and sends a value to
+// started for each request it starts holding.
+func blockingHandler() (http.HandlerFunc, chan<- struct{}, <-chan struct{}) {
+	release, started := make(chan struct{}), make(chan struct{}, 10)
+	return func(w http.ResponseWriter, _ *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}, release, started
+}
+
+func TestBulkhead_Full(t *testing.T) {
+	handler, release, started := blockingHandler()
+	srv := newServer(t, handler)
+	bulkhead := &rest.Bulkhead{MaxConcurrent: 2, MaxQueue: 1}
+	client := &rest.Client{BaseURL: srv.URL, Timeout: 5 * time.Second, Bulkhead: bulkhead}
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Go(func() {
+			response := client.GetWithContext(t.Context(), "/user")
+			assert.NoError(t, response.Err)
+			assert.Equal(t, http.StatusOK, response.StatusCode)
+		})
+	}
+	<-started
+	<-started
+
+	// The third request waits in the queue, so the next one is rejected; probing with a
+	// cancelled context never waits
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	assert.Eventually(t, func() bool {
+		response := client.GetWithContext(ctx, "/user")
+		return errors.Is(response.Err, rest.ErrBulkheadFull)
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 2, bulkhead.InFlight())
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, 0, bulkhead.InFlight())
+}
+
+func TestBulkhead_QueueTimeout(t *testing.T) {
+	handler, release, started := blockingHandler()
+	srv := newServer(t, handler)
+	defer close(release)
+
+	client := &rest.Client{
+		BaseURL:  srv.URL,
+		Timeout:  5 * time.Second,
+		Bulkhead: &rest.Bulkhead{MaxConcurrent: 1, MaxQueue: 5, QueueTimeout: 50 * time.Millisecond},
+	}
+
+	go client.GetWithContext(t.Context(), "/user")
+	<-started
+
+	start := time.Now()
+	response := client.GetWithContext(t.Context(), "/user")
+	var timeoutErr *rest.TimeoutError
+	require.ErrorAs(t, response.Err, &timeoutErr)
+	require.ErrorIs(t, response.Err, context.DeadlineExceeded)
+	assert.Equal(t, rest.TimeoutPhaseQueue, timeoutErr.Phase)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// The caller context also bounds the wait
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	response = client.GetWithContext(ctx, "/user")
+	require.ErrorIs(t, response.Err, context.Canceled)
+}
+
+func TestBulkhead_SetLimit(t *testing.T) {
+	handler, release, started := blockingHandler()
+	srv := newServer(t, handler)
+	bulkhead := &rest.Bulkhead{MaxConcurrent: 1, MaxQueue: 5}
+	client := &rest.Client{BaseURL: srv.URL, Timeout: 5 * time.Second, Bulkhead: bulkhead}
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Go(func() {
+			response := client.GetWithContext(t.Context(), "/user")
+			assert.NoError(t, response.Err)
+		})
+	}
+	<-started
+
+	// Raising the limit admits the queued requests
+	bulkhead.SetLimit(3)
+	<-started
+	<-started
+	assert.Equal(t, 3, bulkhead.InFlight())
+	assert.Equal(t, 3, bulkhead.Limit())
+
+	close(release)
+	wg.Wait()
+}
+
+func TestBulkhead_Adaptive(t *testing.T) {
+	var slow atomic.Bool
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if slow.Load() {
+			time.Sleep(30 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	bulkhead := &rest.Bulkhead{
+		MaxConcurrent: 10,
+		Adaptive:      &rest.AdaptiveLimit{Latency: 20 * time.Millisecond, MinLimit: 2, Backoff: 0.5},
+	}
+	client := &rest.Client{BaseURL: srv.URL, Bulkhead: bulkhead}
+
+	// Slow requests halve the limit down to MinLimit
+	slow.Store(true)
+	for range 3 {
+		response := client.GetWithContext(t.Context(), "/user")
+		require.NoError(t, response.Err)
+	}
+	assert.Equal(t, 2, bulkhead.Limit())
+
+	// Fast requests raise it by one per limit's worth of requests, up to MaxConcurrent
+	slow.Store(false)
+	for range 5 {
+		response := client.GetWithContext(t.Context(), "/user")
+		require.NoError(t, response.Err)
+	}
+	assert.Equal(t, 4, bulkhead.Limit())
+
+	for range 100 {
+		client.GetWithContext(t.Context(), "/user")
+	}
+	assert.Equal(t, 10, bulkhead.Limit())
+}
@@ -0,0 +1,95 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// ErrMissingETag is reported in Response.Err when a conditional request has no ETag to
+// match: the response given to WithIfMatch has none, or the URL has no cached entry.
+// The request is not sent, rather than overwriting the resource unconditionally.
+var ErrMissingETag = errors.New("if-match: no etag to match")
+
+// conditionalVerbs are the HTTP methods of the writes conditioned by WithIfMatch.
+var conditionalVerbs = []string{http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// ConflictError is the error reported for a 412 (Precondition Failed) response, e.g. to a
+// write conditioned with WithIfMatch: the resource changed since its ETag was read. It
+// unwraps to the *StatusError of the response.
+//
+// Example usage:
+//
+//	response := client.R().IfMatch(current).Put(ctx, "/users/{id}", user)
+//	var conflict *rest.ConflictError
+//	if errors.As(response.VerifyIsOkOrError(), &conflict) {
+//	    // fetch the user again, merge and retry
+//	}
+type ConflictError struct {
+	*StatusError
+
+	// ETag is the ETag the request was conditioned on, if any.
+	ETag string
+
+	// CurrentETag is the current ETag of the resource, when the server sent it.
+	CurrentETag string
+}
+
+// Error returns the ETag the request was conditioned on and the status error.
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("conflict: precondition failed for etag %s, %s", e.ETag, e.StatusError.Error())
+}
+
+// Unwrap returns the status error of the response.
+func (e *ConflictError) Unwrap() error {
+	return e.StatusError
+}
+
+// statusError builds the error of a response with an unexpected status code: a
+// *ConflictError for 412 (Precondition Failed), a *StatusError otherwise.
+func statusError(response *Response) error {
+	err := newStatusError(response)
+	if response.StatusCode != http.StatusPreconditionFailed {
+		return err
+	}
+
+	conflict := &ConflictError{StatusError: err, CurrentETag: response.Header.Get(ETagHeader)}
+	if response.Request != nil {
+		conflict.ETag = response.Request.Header.Get(IfMatchHeader)
+	}
+
+	return conflict
+}
+
+// ETag returns the ETag of the response, or an empty string if it has none.
+func (r *Response) ETag() string {
+	if r == nil {
+		return ""
+	}
+
+	return r.etag
+}
+
+// setIfMatch sets the If-Match header of a conditional request to the ETag of the
+// response given to WithIfMatch, or of the cached entry of the URL for WithIfMatchCached.
+// Requests other than PUT, PATCH and DELETE are left unconditional.
+func setIfMatch(request *http.Request, options *requestOptions, cacheURL string) error {
+	if options.ifMatch == nil || !slices.Contains(conditionalVerbs, request.Method) {
+		return nil
+	}
+
+	etag := options.ifMatch(cacheURL)
+	if etag == "" {
+		return fmt.Errorf("%w: %s %s", ErrMissingETag, request.Method, cacheURL)
+	}
+	request.Header.Set(IfMatchHeader, etag)
+
+	return nil
+}
+
+// cachedETag returns the ETag of the cached entry of the URL, if any.
+func cachedETag(cacheURL string) string {
+	cached, _ := resourceCache.get(cacheURL)
+	return cached.ETag()
+}
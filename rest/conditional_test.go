@@ -0,0 +1,133 @@
+package rest_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arielsrv/go-restclient/rest"
+)
+
+// versionedHandler holds a user whose ETag is its version. Writes must match the current
+// ETag with If-Match, and bump the version; reads matching it with If-None-Match are not
+// modified.
+func versionedHandler() http.HandlerFunc {
+	var mtx sync.Mutex
+	user, version := User{ID: 1, Name: "john"}, 1
+	return func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		etag := fmt.Sprintf(`"v%d"`, version)
+		switch {
+		case r.Method == http.MethodGet && r.Header.Get(rest.IfNoneMatchHeader) == etag:
+			w.WriteHeader(http.StatusNotModified)
+			return
+		case r.Method != http.MethodGet:
+			if r.Header.Get(rest.IfMatchHeader) != etag {
+				w.Header().Set(rest.ETagHeader, etag)
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			_ = json.NewDecoder(r.Body).Decode(&user)
+			version++
+			etag = fmt.Sprintf(`"v%d"`, version)
+		}
+
+		w.Header().Set(rest.ETagHeader, etag)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(user)
+	}
+}
+
+func TestIfMatch_Response(t *testing.T) {
+	srv := newServer(t, versionedHandler())
+	client := &rest.Client{BaseURL: srv.URL}
+
+	current := client.GetWithContext(t.Context(), "/users/1")
+	require.NoError(t, current.VerifyIsOkOrError())
+	assert.Equal(t, `"v1"`, current.ETag())
+
+	updated := client.R().IfMatch(current).Put(t.Context(), "/users/1", &User{ID: 1, Name: "jane"})
+	require.NoError(t, updated.VerifyIsOkOrError())
+	assert.Equal(t, `"v2"`, updated.ETag())
+
+	// A second write from the stale response conflicts
+	stale := client.R().IfMatch(current).Patch(t.Context(), "/users/1", &User{ID: 1, Name: "bob"})
+	err := stale.VerifyIsOkOrError()
+	var conflict *rest.ConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, `"v1"`, conflict.ETag)
+	assert.Equal(t, `"v2"`, conflict.CurrentETag)
+
+	// A conflict is still a status error
+	var statusErr *rest.StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusPreconditionFailed, statusErr.StatusCode)
+
+	// Typed helpers report it too
+	ctx := rest.WithOptions(t.Context(), rest.WithIfMatch(current))
+	_, _, err = rest.PutJSON[*User, User](ctx, client, "/users/1", &User{ID: 1, Name: "bob"})
+	require.ErrorAs(t, err, &conflict)
+
+	ctx = rest.WithOptions(t.Context(), rest.WithIfMatch(updated))
+	user, _, err := rest.PutJSON[*User, User](ctx, client, "/users/1", &User{ID: 1, Name: "bob"})
+	require.NoError(t, err)
+	assert.Equal(t, "bob", user.Name)
+}
+
+func TestIfMatch_Cached(t *testing.T) {
+	srv := newServer(t, versionedHandler())
+	client := &rest.Client{BaseURL: srv.URL, EnableCache: true}
+
+	// The cache is filled asynchronously
+	var current *rest.Response
+	assert.Eventually(t, func() bool {
+		current = client.GetWithContext(t.Context(), "/users/1")
+		return current.Cached()
+	}, time.Second, 10*time.Millisecond)
+
+	updated := client.R().IfMatchCached().Delete(t.Context(), "/users/1")
+	require.NoError(t, updated.VerifyIsOkOrError())
+
+	// The successful write evicts the stale entry
+	assert.Eventually(t, func() bool {
+		response := client.R().IfMatchCached().Delete(t.Context(), "/users/1")
+		return errors.Is(response.Err, rest.ErrMissingETag)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestIfMatch_MissingETag(t *testing.T) {
+	srv := newServer(t, versionedHandler())
+	client := &rest.Client{BaseURL: srv.URL}
+
+	response := client.R().IfMatch(&rest.Response{}).Put(t.Context(), "/users/1", &User{ID: 1})
+	require.ErrorIs(t, response.Err, rest.ErrMissingETag)
+	assert.Nil(t, response.Response)
+
+	response = client.R().IfMatchCached().Put(t.Context(), "/users/2", &User{ID: 2})
+	require.ErrorIs(t, response.Err, rest.ErrMissingETag)
+	assert.ErrorContains(t, response.Err, "PUT "+srv.URL+"/users/2")
+}
+
+func TestIfMatch_WritesOnly(t *testing.T) {
+	srv := newServer(t, versionedHandler())
+	client := &rest.Client{BaseURL: srv.URL}
+
+	// Reads and POST requests ignore the condition, even without an ETag
+	ctx := rest.WithOptions(t.Context(), rest.WithIfMatch(&rest.Response{}))
+	response := client.GetWithContext(ctx, "/users/1")
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	response = client.PostWithContext(ctx, "/users", &User{ID: 2})
+	require.NoError(t, response.Err)
+	assert.Empty(t, response.Request.Header.Get(rest.IfMatchHeader))
+}
@@ -0,0 +1,143 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Dialer configures how a Client opens connections: the settings of the default
+// net.Dialer, or a DialContext function replacing it.
+//
+// Example usage:
+//
+//	client := &rest.Client{
+//	    BaseURL: "https://api.example.com",
+//	    Dialer: &rest.Dialer{
+//	        KeepAlive:     30 * time.Second,
+//	        FallbackDelay: -1,     // no dual-stack fallback
+//	        Network:       "tcp4", // IPv4 only
+//	        Resolver:      &net.Resolver{PreferGo: true},
+//	    },
+//	}
+type Dialer struct {
+	// DialContext replaces the default dialer, e.g. to tunnel connections. The connect
+	// timeout is enforced through the deadline of ctx.
+	DialContext func(ctx context.Context, network, address string) (net.Conn, error)
+	// Resolver is the DNS resolver of the default dialer, e.g. a pure Go resolver or one
+	// querying a specific DNS server. The system resolver is used by default.
+	Resolver *net.Resolver
+	// DNSCache caches the lookups of the default dialer and spreads connections across
+	// every resolved address. Lookups are not cached by default.
+	DNSCache *DNSCache
+	// Network restricts TCP connections to "tcp4" or "tcp6", resolving only IPv4 or IPv6
+	// addresses. Both are used by default.
+	Network string
+	// KeepAlive is the TCP keep-alive period, 15 seconds by default. Negative disables it.
+	KeepAlive time.Duration
+	// FallbackDelay is how long a dual-stack connection waits for IPv6 before trying
+	// IPv4, 300 milliseconds by default. Negative disables the fallback.
+	FallbackDelay time.Duration
+}
+
+// unixScheme is the scheme of a BaseURL naming a Unix domain socket.
+const unixScheme = "unix://"
+
+// unixSocket returns the path of the Unix domain socket of the BaseURL, if any.
+func (r *Client) unixSocket() (string, bool) {
+	path, ok := strings.CutPrefix(r.BaseURL, unixScheme)
+	return path, ok && path != ""
+}
+
+// baseURL returns the BaseURL to resolve request URLs against. Requests of a client on
+// a Unix domain socket are sent to http://localhost, over the socket.
+func (r *Client) baseURL() string {
+	if _, ok := r.unixSocket(); ok {
+		return "http://localhost"
+	}
+
+	return r.BaseURL
+}
+
+// socketURL returns the URL identifying the resource of a request URL, for caching and
+// conditional requests. Requests of a client on a Unix domain socket are identified by
+// the BaseURL followed by their path and query, so clients on different sockets never
+// share cached responses. Other URLs are returned as is.
+func (r *Client) socketURL(requestURL string) string {
+	if _, ok := r.unixSocket(); !ok {
+		return requestURL
+	}
+
+	resource, err := url.Parse(requestURL)
+	if err != nil {
+		return requestURL
+	}
+
+	return strings.TrimSuffix(r.BaseURL, "/") + resource.RequestURI()
+}
+
+// dialContext dials with the connect timeout carried by ctx, falling back to the
+// client's ConnectTimeout. The dialer also honours the deadline of ctx, so the
+// effective value is the smaller of both. Expired dials are reported as a connect
+// TimeoutError. Clients on a Unix domain socket dial the socket for every request.
+func (r *Client) dialContext(ctx context.Context, network string, address string) (net.Conn, error) {
+	timeout, ok := ctx.Value(connectTimeoutKey{}).(time.Duration)
+	if !ok {
+		timeout = r.getConnectionTimeout()
+	}
+
+	if socket, isUnix := r.unixSocket(); isUnix {
+		network, address = "unix", socket
+	}
+
+	conn, err := r.dial(ctx, timeout, network, address)
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() && ctx.Err() == nil {
+			return nil, &TimeoutError{
+				Err:   err,
+				Phase: TimeoutPhaseConnect,
+				After: timeout,
+			}
+		}
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// dial opens the connection with the Dialer of the client, or a default net.Dialer.
+func (r *Client) dial(ctx context.Context, timeout time.Duration, network, address string) (net.Conn, error) {
+	if r.Dialer == nil {
+		return (&net.Dialer{Timeout: timeout}).DialContext(ctx, network, address)
+	}
+
+	if r.Dialer.DialContext != nil {
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return r.Dialer.DialContext(ctx, network, address)
+	}
+
+	if r.Dialer.Network != "" && network == "tcp" {
+		network = r.Dialer.Network
+	}
+
+	dialer := &net.Dialer{
+		Timeout:       timeout,
+		KeepAlive:     r.Dialer.KeepAlive,
+		FallbackDelay: r.Dialer.FallbackDelay,
+		Resolver:      r.Dialer.Resolver,
+	}
+
+	if r.Dialer.DNSCache != nil {
+		return r.Dialer.DNSCache.dial(ctx, dialer, network, address)
+	}
+
+	return dialer.DialContext(ctx, network, address)
+}
@@ -0,0 +1,134 @@
+package rest_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arielsrv/go-restclient/rest"
+)
+
+func TestDialer_UnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := (&net.ListenConfig{}).Listen(t.Context(), "unix", socket)
+	require.NoError(t, err)
+
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "localhost", r.Host)
+			_, _ = w.Write([]byte(r.URL.RequestURI()))
+		}),
+		ReadHeaderTimeout: time.Second,
+	}
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+	defer srv.Close()
+
+	client := &rest.Client{BaseURL: "unix://" + socket}
+
+	response := client.GetWithContext(t.Context(), "/containers/json?all=1")
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "/containers/json?all=1", response.String())
+}
+
+func TestDialer_UnixSocket_Cache(t *testing.T) {
+	serve := func(name string) string {
+		socket := filepath.Join(t.TempDir(), name+".sock")
+		listener, err := (&net.ListenConfig{}).Listen(t.Context(), "unix", socket)
+		require.NoError(t, err)
+
+		newServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Cache-Control", "max-age=60")
+			_, _ = w.Write([]byte(name))
+		}), func(srv *httptest.Server) {
+			_ = srv.Listener.Close()
+			srv.Listener = listener
+		})
+
+		return "unix://" + socket
+	}
+	first := &rest.Client{BaseURL: serve("first"), EnableCache: true}
+	second := &rest.Client{BaseURL: serve("second"), EnableCache: true}
+
+	// The cache is filled asynchronously
+	assert.Eventually(t, func() bool {
+		return first.GetWithContext(t.Context(), "/info").Cached()
+	}, time.Second, 10*time.Millisecond)
+
+	response := second.GetWithContext(t.Context(), "/info")
+	require.NoError(t, response.Err)
+	assert.False(t, response.Cached())
+	assert.Equal(t, "second", response.String())
+
+	// Conditional requests look up the ETag of the socket's resource too
+	response = second.R().IfMatchCached().Delete(t.Context(), "/info")
+	require.ErrorIs(t, response.Err, rest.ErrMissingETag)
+	assert.ErrorContains(t, response.Err, "DELETE "+second.BaseURL+"/info")
+}
+
+func TestDialer_DialContext(t *testing.T) {
+	var dials atomic.Int32
+	client := &rest.Client{
+		BaseURL: "http://sidecar.test",
+		Dialer: &rest.Dialer{
+			DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				dials.Add(1)
+				_, ok := ctx.Deadline()
+				assert.True(t, ok)
+				return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+			},
+		},
+	}
+
+	response := client.GetWithContext(t.Context(), "/user")
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, int32(1), dials.Load())
+}
+
+func TestDialer_DialContext_Timeout(t *testing.T) {
+	client := &rest.Client{
+		BaseURL:        "http://sidecar.test",
+		ConnectTimeout: 50 * time.Millisecond,
+		Dialer: &rest.Dialer{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+		},
+	}
+
+	response := client.GetWithContext(t.Context(), "/user")
+	var timeoutErr *rest.TimeoutError
+	require.ErrorAs(t, response.Err, &timeoutErr)
+	assert.Equal(t, rest.TimeoutPhaseConnect, timeoutErr.Phase)
+}
+
+func TestDialer_Network(t *testing.T) {
+	client := &rest.Client{
+		BaseURL: server.URL,
+		Dialer:  &rest.Dialer{Network: "tcp4", KeepAlive: -1, FallbackDelay: -1},
+	}
+
+	response := client.GetWithContext(t.Context(), "/user")
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	client = &rest.Client{
+		BaseURL: server.URL,
+		Dialer:  &rest.Dialer{Network: "tcp6"},
+	}
+
+	response = client.GetWithContext(t.Context(), "/user")
+	require.ErrorContains(t, response.Err, "no suitable address")
+}
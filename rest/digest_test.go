@@ -0,0 +1,210 @@
+package rest_test
+
+import (
+	"crypto/md5" //nolint:gosec // MD5 is mandated by legacy Digest servers
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arielsrv/go-restclient/rest"
+)
+
+// authParam matches the auth-params of a Digest Authorization header.
+var authParam = regexp.MustCompile(`(\w+)=(?:"([^"]*)"|([^,\s]*))`)
+
+// digestServer is an in-process stand-in for a legacy appliance accepting Digest auth
+// with qop=auth. Replayed nonce counts are rejected, and a nonce becomes stale after
+// maxUses requests.
+type digestServer struct {
+	*httptest.Server
+	seen         map[string]map[int64]bool
+	algorithm    string
+	maxUses      int
+	nonces       atomic.Int32
+	challenges   atomic.Int32
+	unauthorized atomic.Int32
+	mtx          sync.Mutex
+}
+
+func newDigestServer(t *testing.T, algorithm string, maxUses int) *digestServer {
+	t.Helper()
+
+	srv := &digestServer{seen: make(map[string]map[int64]bool), algorithm: algorithm, maxUses: maxUses}
+	srv.Server = newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		if !strings.HasPrefix(authorization, "Digest ") {
+			srv.challenge(w, false)
+			return
+		}
+
+		params := make(map[string]string)
+		for _, match := range authParam.FindAllStringSubmatch(authorization, -1) {
+			params[match[1]] = match[2] + match[3]
+		}
+
+		nc, err := strconv.ParseInt(params["nc"], 16, 64)
+		assert.NoError(t, err)
+
+		srv.mtx.Lock()
+		seen, known := srv.seen[params["nonce"]]
+		stale := known && int(nc) > srv.maxUses
+		replayed := !known || seen[nc]
+		if known {
+			seen[nc] = true
+		}
+		srv.mtx.Unlock()
+
+		switch {
+		case stale:
+			srv.challenge(w, true)
+			return
+		case replayed, params["response"] != srv.response(r, params), params["opaque"] != "opaque-value":
+			srv.unauthorized.Add(1)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	return srv
+}
+
+// challenge answers 401 Unauthorized with a new nonce.
+func (r *digestServer) challenge(w http.ResponseWriter, stale bool) {
+	r.challenges.Add(1)
+	nonce := fmt.Sprintf("nonce-%d", r.nonces.Add(1))
+
+	r.mtx.Lock()
+	r.seen[nonce] = make(map[int64]bool)
+	r.mtx.Unlock()
+
+	// Several challenges per header (RFC 7235), SHA-256 after a weaker MD5 one
+	challenges := []string{`Basic realm="appliance"`}
+	if strings.HasPrefix(r.algorithm, "SHA-256") {
+		challenges = append(challenges, fmt.Sprintf(
+			`Digest realm="appliance", qop="auth", algorithm=MD5, nonce="%s", opaque="opaque-value"`, nonce))
+	}
+	challenges = append(challenges, fmt.Sprintf(
+		`Digest realm="appliance", qop="auth,auth-int", algorithm=%s, nonce="%s", opaque="opaque-value", stale=%t`,
+		r.algorithm, nonce, stale))
+
+	w.Header().Add("WWW-Authenticate", `Newauth realm="apps", type=1, title="Login to \"apps\""`)
+	w.Header().Add("WWW-Authenticate", strings.Join(challenges, ", "))
+	w.WriteHeader(http.StatusUnauthorized)
+}
+
+// response returns the expected response of the request.
+func (r *digestServer) response(request *http.Request, params map[string]string) string {
+	newHash := md5.New
+	if strings.HasPrefix(r.algorithm, "SHA-256") {
+		newHash = sha256.New
+	}
+
+	digest := func(h func() hash.Hash, values ...string) string {
+		sum := h()
+		sum.Write([]byte(strings.Join(values, ":")))
+		return hex.EncodeToString(sum.Sum(nil))
+	}
+
+	ha1 := digest(newHash, "admin", "appliance", "secret")
+	if strings.HasSuffix(r.algorithm, "-sess") {
+		ha1 = digest(newHash, ha1, params["nonce"], params["cnonce"])
+	}
+	ha2 := digest(newHash, request.Method, request.URL.RequestURI())
+
+	return digest(newHash, ha1, params["nonce"], params["nc"], params["cnonce"], "auth", ha2)
+}
+
+func TestDigestAuth(t *testing.T) {
+	for _, algorithm := range []string{"MD5", "SHA-256", "MD5-sess", "SHA-256-sess"} {
+		t.Run(algorithm, func(t *testing.T) {
+			srv := newDigestServer(t, algorithm, 100)
+
+			client := &rest.Client{
+				BaseURL:     srv.URL,
+				ContentType: rest.JSON,
+				DigestAuth:  &rest.DigestAuth{Username: "admin", Password: "secret"},
+			}
+
+			response := client.GetWithContext(t.Context(), "/status?verbose=true")
+			require.NoError(t, response.Err)
+			assert.Equal(t, http.StatusOK, response.StatusCode)
+
+			response = client.PostWithContext(t.Context(), "/users", User{Name: "Maria"})
+			require.NoError(t, response.Err)
+			assert.Equal(t, http.StatusOK, response.StatusCode)
+
+			// Only the first request is challenged, the nonce is reused afterward
+			assert.Equal(t, int32(1), srv.challenges.Load())
+			assert.Equal(t, int32(0), srv.unauthorized.Load())
+		})
+	}
+}
+
+func TestDigestAuth_StaleNonce(t *testing.T) {
+	srv := newDigestServer(t, "SHA-256", 3)
+
+	client := &rest.Client{
+		BaseURL:    srv.URL,
+		DigestAuth: &rest.DigestAuth{Username: "admin", Password: "secret"},
+	}
+
+	for range 10 {
+		response := client.GetWithContext(t.Context(), "/status")
+		require.NoError(t, response.Err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+	}
+
+	assert.Equal(t, int32(4), srv.challenges.Load())
+	assert.Equal(t, int32(0), srv.unauthorized.Load())
+}
+
+func TestDigestAuth_Concurrent(t *testing.T) {
+	srv := newDigestServer(t, "SHA-256", 1000)
+
+	client := &rest.Client{
+		BaseURL:    srv.URL,
+		DigestAuth: &rest.DigestAuth{Username: "admin", Password: "secret"},
+	}
+
+	response := client.GetWithContext(t.Context(), "/status")
+	require.NoError(t, response.Err)
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Go(func() {
+			response := client.GetWithContext(t.Context(), "/status")
+			assert.NoError(t, response.Err)
+			assert.Equal(t, http.StatusOK, response.StatusCode)
+		})
+	}
+	wg.Wait()
+}
+
+func TestDigestAuth_WrongPassword(t *testing.T) {
+	srv := newDigestServer(t, "MD5", 100)
+
+	client := &rest.Client{
+		BaseURL:    srv.URL,
+		DigestAuth: &rest.DigestAuth{Username: "admin", Password: "wrong"},
+	}
+
+	response := client.GetWithContext(t.Context(), "/status")
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+	assert.Equal(t, int32(1), srv.challenges.Load())
+	assert.Equal(t, int32(1), srv.unauthorized.Load())
+}
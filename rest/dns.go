@@ -0,0 +1,292 @@
+package rest
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// DNS cache defaults.
+const (
+	// DefaultDNSTTL is how long resolved addresses are cached by default.
+	DefaultDNSTTL = time.Minute
+
+	// DefaultDNSNegativeTTL is how long a failed lookup is cached by default.
+	DefaultDNSNegativeTTL = 5 * time.Second
+
+	// DefaultDNSMaxStale is how long expired addresses are served by default when
+	// refreshing them fails.
+	DefaultDNSMaxStale = 10 * time.Minute
+)
+
+// Resolver looks up the IP addresses of a host. *net.Resolver implements it.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// DNSCache is an in-process DNS cache for the dialer of a Client, set in Dialer.DNSCache.
+// It can be shared by several clients.
+//
+// Resolved addresses are cached for TTL and failed lookups for NegativeTTL. When the
+// addresses of a host expire and the lookup fails, the expired addresses keep being
+// used for up to MaxStale, so a flaky resolver does not fail requests. Concurrent
+// lookups of a host are merged into one.
+//
+// Connections are spread across every resolved A and AAAA record in turn, falling
+// back to the next address when one fails to connect. As with net.Dialer, when a host
+// has both, the addresses of the other family are raced after Dialer.FallbackDelay.
+//
+// Example usage:
+//
+//	client := &rest.Client{
+//	    BaseURL: "https://api.example.com",
+//	    Dialer: &rest.Dialer{
+//	        DNSCache: &rest.DNSCache{TTL: 30 * time.Second},
+//	    },
+//	}
+type DNSCache struct {
+	// Resolver looks up the hosts, Dialer.Resolver or the system resolver by default.
+	Resolver Resolver
+	entries  map[string]*dnsEntry
+	group    singleflight.Group
+	// TTL is how long resolved addresses are cached, DefaultDNSTTL by default.
+	TTL time.Duration
+	// NegativeTTL is how long a failed lookup is cached, DefaultDNSNegativeTTL by default.
+	// Negative disables negative caching.
+	NegativeTTL time.Duration
+	// MaxStale is how long expired addresses are served when refreshing them fails,
+	// DefaultDNSMaxStale by default. Negative disables serving stale addresses.
+	MaxStale time.Duration
+	mtx      sync.Mutex
+}
+
+// dnsEntry is the cached lookup of a host.
+type dnsEntry struct {
+	expires    time.Time
+	staleUntil time.Time
+	err        error
+	addrs      []net.IPAddr
+	next       atomic.Uint32
+}
+
+// lookup returns the cached addresses of the host, resolving them when missing or expired.
+func (r *DNSCache) lookup(ctx context.Context, resolver Resolver, host string) (*dnsEntry, error) {
+	r.mtx.Lock()
+	entry := r.entries[host]
+	r.mtx.Unlock()
+
+	if entry != nil && time.Now().Before(entry.expires) {
+		return entry, entry.err
+	}
+
+	// The lookup is shared by concurrent dials, so it outlives the context of the first
+	// one, while every dial stops waiting for it when its own context is done
+	results := r.group.DoChan(host, func() (any, error) {
+		addrs, err := resolver.LookupIPAddr(context.WithoutCancel(ctx), host)
+		return r.store(host, entry, addrs, err), nil
+	})
+
+	select {
+	case result := <-results:
+		entry = result.Val.(*dnsEntry)
+		return entry, entry.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// store caches the result of a lookup and returns the entry to use: the new addresses,
+// the previous ones while they are not too stale, or the error. Stale addresses and
+// errors are both kept for NegativeTTL before the host is looked up again.
+func (r *DNSCache) store(host string, previous *dnsEntry, addrs []net.IPAddr, err error) *dnsEntry {
+	now := time.Now()
+	retry := now.Add(cmp.Or(r.NegativeTTL, DefaultDNSNegativeTTL))
+
+	var entry *dnsEntry
+	switch {
+	case err == nil && len(addrs) == 0:
+		err = &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		fallthrough
+	case err != nil:
+		if previous != nil && previous.err == nil && now.Before(previous.staleUntil) {
+			entry = &dnsEntry{addrs: previous.addrs, expires: retry, staleUntil: previous.staleUntil}
+		} else {
+			entry = &dnsEntry{err: err, expires: retry}
+		}
+	default:
+		expires := now.Add(cmp.Or(r.TTL, DefaultDNSTTL))
+		entry = &dnsEntry{
+			addrs:      addrs,
+			expires:    expires,
+			staleUntil: expires.Add(cmp.Or(r.MaxStale, DefaultDNSMaxStale)),
+		}
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.entries == nil {
+		r.entries = make(map[string]*dnsEntry)
+	}
+	r.entries[host] = entry
+
+	return entry
+}
+
+// dial connects to the address through the cached addresses of its host, starting with
+// the next address in turn and falling back to the following ones when one fails.
+func (r *DNSCache) dial(ctx context.Context, dialer *net.Dialer, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || net.ParseIP(host) != nil {
+		return dialer.DialContext(ctx, network, address)
+	}
+
+	resolver := r.Resolver
+	if resolver == nil {
+		resolver = cmp.Or(dialer.Resolver, net.DefaultResolver)
+	}
+
+	entry, err := r.lookup(ctx, resolver, host)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := make([]net.IPAddr, 0, len(entry.addrs))
+	for _, addr := range entry.addrs {
+		if network == "tcp" || (network == "tcp4") == (addr.IP.To4() != nil) {
+			addrs = append(addrs, addr)
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, &net.AddrError{Err: "no suitable address found", Addr: host}
+	}
+
+	// The connect timeout bounds every attempt together
+	if dialer.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, dialer.Timeout)
+		defer cancel()
+	}
+	attempt := *dialer
+	attempt.Timeout = 0
+
+	start := int(entry.next.Add(1)-1) % len(addrs)
+	addrs = slices.Concat(addrs[start:], addrs[:start])
+
+	// The family of the first address is dialed first, the other one after the delay
+	if dialer.FallbackDelay < 0 {
+		return dialSerial(ctx, &attempt, network, port, addrs)
+	}
+
+	var primaries, fallbacks []net.IPAddr
+	for _, addr := range addrs {
+		if (addr.IP.To4() != nil) == (addrs[0].IP.To4() != nil) {
+			primaries = append(primaries, addr)
+		} else {
+			fallbacks = append(fallbacks, addr)
+		}
+	}
+
+	return dialParallel(ctx, &attempt, network, port, primaries, fallbacks)
+}
+
+// dialResult is the outcome of dialSerial in a race of dialParallel.
+type dialResult struct {
+	conn    net.Conn
+	err     error
+	primary bool
+	done    bool
+}
+
+// dialParallel races the primary addresses against the fallback ones, dialed after the
+// FallbackDelay of the dialer, 300ms by default, or as soon as the primaries fail. The
+// first connection wins and the other one is closed. When both fail, the error of the
+// primaries is returned.
+func dialParallel(
+	ctx context.Context,
+	dialer *net.Dialer,
+	network, port string,
+	primaries, fallbacks []net.IPAddr,
+) (net.Conn, error) {
+	if len(fallbacks) == 0 {
+		return dialSerial(ctx, dialer, network, port, primaries)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	returned := make(chan struct{})
+	defer close(returned)
+
+	results := make(chan dialResult)
+	race := func(primary bool, addrs []net.IPAddr) {
+		conn, err := dialSerial(ctx, dialer, network, port, addrs)
+		select {
+		case results <- dialResult{conn: conn, err: err, primary: primary, done: true}:
+		case <-returned:
+			if conn != nil {
+				_ = conn.Close()
+			}
+		}
+	}
+
+	go race(true, primaries)
+
+	fallbackTimer := time.NewTimer(cmp.Or(dialer.FallbackDelay, 300*time.Millisecond))
+	defer fallbackTimer.Stop()
+
+	var primary, fallback dialResult
+	for {
+		select {
+		case <-fallbackTimer.C:
+			go race(false, fallbacks)
+		case result := <-results:
+			if result.err == nil {
+				return result.conn, nil
+			}
+
+			if result.primary {
+				primary = result
+			} else {
+				fallback = result
+			}
+			if primary.done && fallback.done {
+				return nil, primary.err
+			}
+			// Failed primaries start the fallbacks at once
+			if result.primary && fallbackTimer.Stop() {
+				fallbackTimer.Reset(0)
+			}
+		}
+	}
+}
+
+// dialSerial connects to the addresses in order, returning the first connection.
+func dialSerial(ctx context.Context, dialer *net.Dialer, network, port string, addrs []net.IPAddr) (net.Conn, error) {
+	var errs []error
+	for _, addr := range addrs {
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(addr.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+
+		// An expired connect timeout is reported as is, so it is seen as a timeout
+		if ctx.Err() != nil {
+			return nil, err
+		}
+	}
+
+	if len(errs) == 1 {
+		return nil, errs[0]
+	}
+
+	return nil, errors.Join(errs...)
+}
@@ -0,0 +1,56 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// staticResolver resolves every host to its addresses.
+type staticResolver []net.IPAddr
+
+func (r staticResolver) LookupIPAddr(context.Context, string) ([]net.IPAddr, error) {
+	return r, nil
+}
+
+func Test_dialParallel_fallback(t *testing.T) {
+	listener, err := (&net.ListenConfig{}).Listen(t.Context(), "tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	defer listener.Close()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	// IPv6 dials hang until the end of the test
+	blocked := make(chan struct{})
+	defer close(blocked)
+	dialer := &net.Dialer{
+		FallbackDelay: 20 * time.Millisecond,
+		Control: func(network, _ string, _ syscall.RawConn) error {
+			if network == "tcp6" {
+				<-blocked
+				return errors.New("blocked")
+			}
+			return nil
+		},
+	}
+
+	cache := &DNSCache{Resolver: staticResolver{{IP: net.ParseIP("::1")}, {IP: net.ParseIP("127.0.0.1")}}}
+
+	start := time.Now()
+	conn, err := cache.dial(t.Context(), dialer, "tcp", net.JoinHostPort("api.cached.test", port))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	defer conn.Close()
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("fallback dialed after %v", elapsed)
+	}
+	if host, _, _ := net.SplitHostPort(conn.RemoteAddr().String()); host != "127.0.0.1" {
+		t.Errorf("unexpected remote address: %s", conn.RemoteAddr())
+	}
+}
@@ -0,0 +1,209 @@
+package rest_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arielsrv/go-restclient/rest"
+)
+
+// fakeResolver resolves every host to the given addresses until it starts failing.
+type fakeResolver struct {
+	err     atomic.Pointer[error]
+	addrs   []net.IPAddr
+	delay   time.Duration
+	lookups atomic.Int32
+}
+
+func (r *fakeResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	r.lookups.Add(1)
+	time.Sleep(r.delay)
+
+	if err := r.err.Load(); err != nil {
+		return nil, &net.DNSError{Err: (*err).Error(), Name: host, IsTemporary: true}
+	}
+
+	return r.addrs, nil
+}
+
+func (r *fakeResolver) fail(err error) {
+	r.err.Store(&err)
+}
+
+func ipAddrs(ips ...string) []net.IPAddr {
+	addrs := make([]net.IPAddr, len(ips))
+	for i, ip := range ips {
+		addrs[i] = net.IPAddr{IP: net.ParseIP(ip)}
+	}
+
+	return addrs
+}
+
+// newLoopbackServers starts a server on each loopback IP, all on the same port. Every
+// response closes the connection, so each request dials again. It returns the port
+// and the number of requests served by each IP.
+func newLoopbackServers(t *testing.T, ips ...string) (string, map[string]*atomic.Int32) {
+	t.Helper()
+
+	port, listeners := "0", make([]net.Listener, 0, len(ips))
+	for attempt := 0; len(listeners) < len(ips); attempt++ {
+		if attempt == 10 {
+			t.Fatal("no free port on every loopback address")
+		}
+
+		for _, listener := range listeners {
+			_ = listener.Close()
+		}
+		port, listeners = "0", listeners[:0]
+
+		for _, ip := range ips {
+			listener, err := (&net.ListenConfig{}).Listen(t.Context(), "tcp", net.JoinHostPort(ip, port))
+			if err != nil {
+				break
+			}
+			_, port, _ = net.SplitHostPort(listener.Addr().String())
+			listeners = append(listeners, listener)
+		}
+	}
+
+	hits := make(map[string]*atomic.Int32)
+	for i, ip := range ips {
+		counter := new(atomic.Int32)
+		hits[ip] = counter
+		newServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			counter.Add(1)
+			w.Header().Set("Connection", "close")
+			w.WriteHeader(http.StatusOK)
+		}), func(srv *httptest.Server) {
+			_ = srv.Listener.Close()
+			srv.Listener = listeners[i]
+		})
+	}
+
+	return port, hits
+}
+
+func TestDNSCache_RoundRobin(t *testing.T) {
+	port, hits := newLoopbackServers(t, "127.0.0.1", "127.0.0.2")
+
+	// 127.0.0.3 refuses connections, so dials fall back to the next address
+	resolver := &fakeResolver{addrs: ipAddrs("127.0.0.1", "127.0.0.3", "127.0.0.2")}
+	client := &rest.Client{
+		BaseURL: "http://api.cached.test:" + port,
+		Dialer:  &rest.Dialer{DNSCache: &rest.DNSCache{Resolver: resolver}},
+	}
+
+	for range 6 {
+		response := client.GetWithContext(t.Context(), "/")
+		require.NoError(t, response.Err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+	}
+
+	assert.Equal(t, int32(1), resolver.lookups.Load())
+	assert.Equal(t, int32(2), hits["127.0.0.1"].Load())
+	assert.Equal(t, int32(4), hits["127.0.0.2"].Load())
+}
+
+func TestDNSCache_ServeStale(t *testing.T) {
+	port, hits := newLoopbackServers(t, "127.0.0.1")
+
+	resolver := &fakeResolver{addrs: ipAddrs("127.0.0.1")}
+	client := &rest.Client{
+		BaseURL: "http://api.cached.test:" + port,
+		Dialer: &rest.Dialer{DNSCache: &rest.DNSCache{
+			Resolver:    resolver,
+			TTL:         20 * time.Millisecond,
+			NegativeTTL: time.Minute,
+			MaxStale:    time.Minute,
+		}},
+	}
+
+	response := client.GetWithContext(t.Context(), "/")
+	require.NoError(t, response.Err)
+
+	resolver.fail(errors.New("server misbehaving"))
+	time.Sleep(30 * time.Millisecond)
+
+	for range 3 {
+		response = client.GetWithContext(t.Context(), "/")
+		require.NoError(t, response.Err)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+	}
+
+	// The failed refresh is not retried until NegativeTTL
+	assert.Equal(t, int32(2), resolver.lookups.Load())
+	assert.Equal(t, int32(4), hits["127.0.0.1"].Load())
+}
+
+func TestDNSCache_Negative(t *testing.T) {
+	resolver := &fakeResolver{}
+	resolver.fail(errors.New("no such host"))
+
+	client := &rest.Client{
+		BaseURL: "http://missing.cached.test",
+		Dialer:  &rest.Dialer{DNSCache: &rest.DNSCache{Resolver: resolver}},
+	}
+
+	for range 3 {
+		response := client.GetWithContext(t.Context(), "/")
+		var dnsErr *net.DNSError
+		require.ErrorAs(t, response.Err, &dnsErr)
+	}
+
+	assert.Equal(t, int32(1), resolver.lookups.Load())
+}
+
+func TestDNSCache_ConcurrentLookups(t *testing.T) {
+	port, _ := newLoopbackServers(t, "127.0.0.1")
+
+	resolver := &fakeResolver{addrs: ipAddrs("127.0.0.1"), delay: 20 * time.Millisecond}
+	client := &rest.Client{
+		BaseURL: "http://api.cached.test:" + port,
+		Dialer:  &rest.Dialer{DNSCache: &rest.DNSCache{Resolver: resolver}},
+	}
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Go(func() {
+			response := client.GetWithContext(t.Context(), "/")
+			assert.NoError(t, response.Err)
+		})
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), resolver.lookups.Load())
+}
+
+func TestDNSCache_LookupCanceled(t *testing.T) {
+	port, _ := newLoopbackServers(t, "127.0.0.1")
+
+	resolver := &fakeResolver{addrs: ipAddrs("127.0.0.1"), delay: 200 * time.Millisecond}
+	client := &rest.Client{
+		BaseURL: "http://api.cached.test:" + port,
+		Dialer:  &rest.Dialer{DNSCache: &rest.DNSCache{Resolver: resolver}},
+	}
+
+	// The dial stops waiting for the lookup, which completes in the background
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	response := client.GetWithContext(ctx, "/")
+	require.ErrorIs(t, response.Err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 150*time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		return client.GetWithContext(t.Context(), "/").Err == nil
+	}, time.Second, 50*time.Millisecond)
+	assert.Equal(t, int32(1), resolver.lookups.Load())
+}
@@ -0,0 +1,262 @@
+package rest
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Hedging defaults.
+const (
+	// DefaultHedgePercentile is the percentile of recent latencies used as the hedging
+	// delay by default.
+	DefaultHedgePercentile = 0.95
+
+	// DefaultHedgeMaxRatio is the maximum fraction of requests hedged by default.
+	DefaultHedgeMaxRatio = 0.1
+)
+
+const (
+	// hedgeSamples is the number of recent latencies the hedging delay is computed from.
+	hedgeSamples = 256
+
+	// hedgeMinSamples is the number of latencies needed before hedging on a percentile.
+	hedgeMinSamples = 20
+
+	// hedgeCost is the price of a hedge in budget tokens, so that ratios are summed exactly.
+	hedgeCost = 1000
+)
+
+// Hedging sends a second copy of a slow read request, cutting tail latency against
+// replicated backends. When a GET, HEAD or OPTIONS request without a body has not
+// answered within the hedging delay, a hedge is sent, to another endpoint of BaseURLs
+// when there are several. The first successful response, with a status below 500, wins
+// and the other request is cancelled; Response.Hedged reports whether it was the hedge.
+//
+// The delay is either fixed or the Percentile of the latencies of recent requests; in
+// the latter case nothing is hedged until enough requests completed. MaxRatio caps the
+// extra load: hedges are paid with tokens earned by every eligible request.
+//
+// Example usage:
+//
+//	client := &rest.Client{
+//	    BaseURLs: []string{"http://10.0.0.1:8080", "http://10.0.0.2:8080"},
+//	    Hedging: &rest.Hedging{
+//	        Percentile: 0.95, // hedge requests slower than the recent p95
+//	        MaxRatio:   0.05, // hedge at most 5% of the requests
+//	    },
+//	}
+type Hedging struct {
+	// Delay is how long a request waits before it is hedged. Zero uses Percentile.
+	Delay time.Duration
+	// Percentile of the latencies of recent requests used as the delay when Delay is
+	// zero, DefaultHedgePercentile by default.
+	Percentile float64
+	// MaxRatio is the maximum fraction of requests hedged, DefaultHedgeMaxRatio by default.
+	MaxRatio float64
+}
+
+// hedger tracks the latencies and the hedging budget of a client.
+type hedger struct {
+	config    Hedging
+	latencies []time.Duration
+	next      int
+	tokens    int
+	mtx       sync.Mutex
+}
+
+// hedgeAttempt is a request sent by hedge, the first one or the hedge.
+type hedgeAttempt struct {
+	response *http.Response
+	err      error
+	endpoint *endpoint
+	cancel   context.CancelFunc
+	index    int
+}
+
+// succeeded reports whether the attempt answered with a status below 500.
+func (r *hedgeAttempt) succeeded() bool {
+	return r != nil && r.err == nil && r.response.StatusCode < http.StatusInternalServerError
+}
+
+// discard releases an attempt that lost.
+func (r *hedgeAttempt) discard() {
+	if r.response != nil {
+		_ = r.response.Body.Close()
+	}
+	r.cancel()
+	r.endpoint.release()
+}
+
+// getHedger returns the hedging state of the client.
+func (r *Client) getHedger() *hedger {
+	r.hedgerOnce.Do(func() {
+		r.hedger = &hedger{
+			config:    *r.Hedging,
+			latencies: make([]time.Duration, 0, hedgeSamples),
+		}
+	})
+
+	return r.hedger
+}
+
+// delay returns the hedging delay, or false while there are too few latencies to
+// compute it from.
+func (r *hedger) delay() (time.Duration, bool) {
+	if r.config.Delay > 0 {
+		return r.config.Delay, true
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if len(r.latencies) < hedgeMinSamples {
+		return 0, false
+	}
+
+	sorted := slices.Clone(r.latencies)
+	slices.Sort(sorted)
+	percentile := cmp.Or(r.config.Percentile, DefaultHedgePercentile)
+	index := int(math.Ceil(percentile*float64(len(sorted)))) - 1
+
+	return sorted[min(max(index, 0), len(sorted)-1)], true
+}
+
+// observe records the latency of a request that answered.
+func (r *hedger) observe(latency time.Duration) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if len(r.latencies) < hedgeSamples {
+		r.latencies = append(r.latencies, latency)
+		return
+	}
+
+	r.latencies[r.next] = latency
+	r.next = (r.next + 1) % hedgeSamples
+}
+
+// earn adds the share of a hedge paid by an eligible request to the budget. The budget
+// is capped to the hedges allowed over about a hundred requests.
+func (r *hedger) earn() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	share := int(cmp.Or(r.config.MaxRatio, DefaultHedgeMaxRatio) * hedgeCost)
+	r.tokens = min(r.tokens+share, max(share*100, hedgeCost))
+}
+
+// spend takes a hedge from the budget, reporting whether there was one.
+func (r *hedger) spend() bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.tokens < hedgeCost {
+		return false
+	}
+	r.tokens -= hedgeCost
+
+	return true
+}
+
+// hedge sends the request to its endpoint and, when it has not answered within the
+// hedging delay, sends the hedge built by newHedge for another endpoint. It returns the
+// first successful response, or the first failure when every request failed, with the
+// endpoint it came from and whether it was the hedge. The other endpoints are released.
+func (r *Client) hedge(
+	ctx context.Context,
+	httpClient *http.Client,
+	request *http.Request,
+	primary *endpoint,
+	newHedge func(ctx context.Context, endpoint *endpoint) (*http.Request, error),
+) (*http.Response, *endpoint, bool, error) {
+	hedger := r.getHedger()
+	hedger.earn()
+
+	attempts := make(chan *hedgeAttempt, 2)
+	var cancels []context.CancelFunc
+	send := func(request *http.Request, endpoint *endpoint, cancel context.CancelFunc) {
+		attempt := &hedgeAttempt{endpoint: endpoint, cancel: cancel, index: len(cancels)}
+		cancels = append(cancels, cancel)
+
+		go func() {
+			start := time.Now()
+			attempt.response, attempt.err = httpClient.Do(request)
+			endpoint.observe(request.Context(), attempt.response, attempt.err)
+			if attempt.err == nil {
+				hedger.observe(time.Since(start))
+			}
+			attempts <- attempt
+		}()
+	}
+
+	primaryCtx, cancel := context.WithCancel(ctx)
+	send(request.WithContext(primaryCtx), primary, cancel)
+
+	var timer <-chan time.Time
+	if delay, ok := hedger.delay(); ok {
+		t := time.NewTimer(delay)
+		defer t.Stop()
+		timer = t.C
+	}
+
+	var result *hedgeAttempt
+	inflight := 1
+	for inflight > 0 && !result.succeeded() {
+		select {
+		case <-timer:
+			timer = nil
+			if !hedger.spend() {
+				continue
+			}
+
+			endpoint := r.pickEndpointExcept(primary)
+			hedgeCtx, hedgeCancel := context.WithCancel(ctx)
+			hedgeRequest, err := newHedge(hedgeCtx, endpoint)
+			if err != nil {
+				hedgeCancel()
+				endpoint.release()
+				continue
+			}
+
+			send(hedgeRequest, endpoint, hedgeCancel)
+			inflight++
+		case attempt := <-attempts:
+			inflight--
+			if result == nil || attempt.succeeded() {
+				if result != nil {
+					result.discard()
+				}
+				result = attempt
+			} else {
+				attempt.discard()
+			}
+		}
+	}
+
+	// The requests still in flight lost: they are cancelled and released once they return
+	for i, cancel := range cancels {
+		if i != result.index {
+			cancel()
+		}
+	}
+	go func() {
+		for range inflight {
+			(<-attempts).discard()
+		}
+	}()
+
+	if result.err != nil {
+		result.cancel()
+		return nil, result.endpoint, result.index > 0, result.err
+	}
+
+	// The winner is cancelled once its body is closed
+	result.response.Body = &streamBody{Reader: result.response.Body, body: result.response.Body, cancel: result.cancel}
+
+	return result.response, result.endpoint, result.index > 0, nil
+}
@@ -0,0 +1,133 @@
+package rest_test
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arielsrv/go-restclient/rest"
+)
+
+func TestHedging_SlowReplica(t *testing.T) {
+	var slowHits atomic.Int32
+	cancelled := make(chan struct{})
+	slow := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Only the first request is slow
+		if slowHits.Add(1) == 1 {
+			<-r.Context().Done()
+			close(cancelled)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	_, urls := newReplicas(t, 1, nil)
+	client := &rest.Client{
+		BaseURLs: []string{slow.URL, urls[0]},
+		Timeout:  2 * time.Second,
+		Hedging:  &rest.Hedging{Delay: 20 * time.Millisecond, MaxRatio: 1},
+	}
+
+	start := time.Now()
+	response := client.GetWithContext(t.Context(), "/user")
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.True(t, response.Hedged)
+	assert.Equal(t, urls[0], response.Endpoint)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the slow request was not cancelled")
+	}
+
+	// Fast requests are not hedged
+	response = client.GetWithContext(t.Context(), "/user")
+	require.NoError(t, response.Err)
+	assert.False(t, response.Hedged)
+	assert.Equal(t, slow.URL, response.Endpoint)
+}
+
+func TestHedging_Percentile(t *testing.T) {
+	var slowHits atomic.Int32
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first copy of a slow request hangs, the hedge answers right away
+		if r.URL.Path == "/slow" && slowHits.Add(1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+
+	client := &rest.Client{
+		BaseURL: srv.URL,
+		Timeout: 2 * time.Second,
+		Hedging: &rest.Hedging{Percentile: 0.9, MaxRatio: 1},
+	}
+
+	// Nothing is hedged until enough latencies are known
+	for range 25 {
+		response := client.GetWithContext(t.Context(), "/fast")
+		require.NoError(t, response.Err)
+		assert.False(t, response.Hedged)
+	}
+
+	response := client.GetWithContext(t.Context(), "/slow")
+	require.NoError(t, response.Err)
+	assert.True(t, response.Hedged)
+	assert.Equal(t, "/slow", response.String())
+	assert.Equal(t, int32(2), slowHits.Load())
+}
+
+func TestHedging_MaxRatio(t *testing.T) {
+	var hits atomic.Int32
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	client := &rest.Client{
+		BaseURL: srv.URL,
+		Hedging: &rest.Hedging{Delay: time.Millisecond, MaxRatio: 0.1},
+	}
+
+	hedged := 0
+	for range 50 {
+		response := client.GetWithContext(t.Context(), "/user")
+		require.NoError(t, response.Err)
+		if response.Hedged {
+			hedged++
+		}
+	}
+
+	// Every request is slow, but at most 10% of them are hedged
+	assert.Positive(t, hits.Load()-50)
+	assert.LessOrEqual(t, hits.Load()-50, int32(5))
+	assert.LessOrEqual(t, hedged, 5)
+}
+
+func TestHedging_UnsafeVerbs(t *testing.T) {
+	var hits atomic.Int32
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	client := &rest.Client{
+		BaseURL: srv.URL,
+		Hedging: &rest.Hedging{Delay: time.Millisecond, MaxRatio: 1},
+	}
+
+	response := client.PostWithContext(t.Context(), "/users", &User{ID: 1})
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+	assert.False(t, response.Hedged)
+	assert.Equal(t, int32(1), hits.Load())
+}
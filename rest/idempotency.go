@@ -0,0 +1,118 @@
+package rest
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// IdempotencyKeyHeader is the header name for the key identifying a logical operation,
+// so the server performs it at most once however many times the request is sent.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader is the header name set by servers answering with the stored
+// result of an operation already performed under the same Idempotency-Key.
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// unsafeVerbs contains HTTP methods that are neither safe nor idempotent.
+// These methods get an Idempotency-Key when Client.Idempotency is set.
+var unsafeVerbs = []string{http.MethodPost, http.MethodPatch}
+
+// ErrIdempotencyKeyRequired is reported in Response.Err when a POST or PATCH request to a
+// route listed in Idempotency.Required has no Idempotency-Key.
+var ErrIdempotencyKeyRequired = errors.New("idempotency key required")
+
+// Idempotency adds an Idempotency-Key header to the POST and PATCH requests of a Client.
+// The key is sent unchanged on every retry, and POST and PATCH requests carrying one are
+// retried like idempotent ones (see Retry).
+//
+// Requests without a key get a new one from NewKey, except on Required routes: the caller
+// must supply the key of the operation with WithIdempotencyKey, and keep it when calling
+// again, e.g. after a crash. Required are path.Match patterns, such as "/payments" or
+// "/orders/*/refunds", matched against the route template of the request, or else the
+// path of the URL given to the request, without the path of BaseURL.
+//
+// Example usage:
+//
+//	client := &rest.Client{
+//	    BaseURL:     "https://payments.example.com",
+//	    Idempotency: &rest.Idempotency{Required: []string{"/payments"}},
+//	}
+//
+//	key := rest.NewIdempotencyKey() // stored with the operation
+//	ctx = rest.WithOptions(ctx, rest.WithIdempotencyKey(key))
+//	response := client.PostWithContext(ctx, "/payments", payment)
+//	if response.IdempotentReplayed() {
+//	    // the payment was already made, response holds its stored result
+//	}
+type Idempotency struct {
+	// NewKey returns the key of a request without one, NewIdempotencyKey by default.
+	NewKey func() string
+	// Required are the routes whose POST and PATCH requests fail with
+	// ErrIdempotencyKeyRequired without a key supplied by the caller.
+	Required []string
+}
+
+// NewIdempotencyKey returns a new random key (UUID version 4) for an Idempotency-Key header.
+func NewIdempotencyKey() string {
+	var uuid [16]byte
+	_, _ = rand.Read(uuid[:])
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])
+}
+
+// IdempotentReplayed reports whether the server answered with the stored result of an
+// operation already performed under the same Idempotency-Key.
+func (r *Response) IdempotentReplayed() bool {
+	if r.Response == nil {
+		return false
+	}
+
+	replayed, err := strconv.ParseBool(r.Header.Get(IdempotentReplayedHeader))
+	return err == nil && replayed
+}
+
+// setIdempotencyKey sets the Idempotency-Key header of the request: the key of the
+// request options, a key already in the headers, or a new one for POST and PATCH. It
+// fails for POST and PATCH requests to a Required route without a key, apiURL being
+// the URL given to the request, before it is resolved against the base URL.
+func (r *Client) setIdempotencyKey(request *http.Request, options *requestOptions, apiURL string) error {
+	if options.idempotencyKey != "" {
+		request.Header.Set(IdempotencyKeyHeader, options.idempotencyKey)
+		return nil
+	}
+
+	if r.Idempotency == nil || !slices.Contains(unsafeVerbs, request.Method) ||
+		request.Header.Get(IdempotencyKeyHeader) != "" {
+		return nil
+	}
+
+	route := RouteFromContext(request.Context())
+	if route == "" {
+		route, _, _ = strings.Cut(apiURL, "?")
+		if parsed, err := url.Parse(apiURL); err == nil {
+			route = parsed.Path
+		}
+	}
+	for _, pattern := range r.Idempotency.Required {
+		if matched, _ := path.Match(pattern, route); matched {
+			return fmt.Errorf("%w: %s %s", ErrIdempotencyKeyRequired, request.Method, route)
+		}
+	}
+
+	newKey := r.Idempotency.NewKey
+	if newKey == nil {
+		newKey = NewIdempotencyKey
+	}
+	request.Header.Set(IdempotencyKeyHeader, newKey())
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package rest_test
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arielsrv/go-restclient/rest"
+)
+
+// idempotentHandler fails the first request of every key with a 503, replays the stored
+// result on later ones, and records the keys it received in order.
+func idempotentHandler() (http.HandlerFunc, func() []string) {
+	var mtx sync.Mutex
+	var keys []string
+	seen := make(map[string]int)
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		key := r.Header.Get(rest.IdempotencyKeyHeader)
+		keys = append(keys, key)
+		seen[key]++
+		switch {
+		case key == "" || seen[key] == 2:
+			w.WriteHeader(http.StatusCreated)
+		case seen[key] == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Header().Set(rest.IdempotentReplayedHeader, "true")
+			w.WriteHeader(http.StatusCreated)
+		}
+	}
+
+	return handler, func() []string {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return append([]string(nil), keys...)
+	}
+}
+
+func TestIdempotency_RetryReusesKey(t *testing.T) {
+	handler, keys := idempotentHandler()
+	srv := newServer(t, handler)
+	client := &rest.Client{
+		BaseURL:     srv.URL,
+		Idempotency: &rest.Idempotency{},
+		Retry:       &rest.Retry{Backoff: time.Millisecond},
+	}
+
+	response := client.PostWithContext(t.Context(), "/users", &User{ID: 1, Name: "john"})
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+	assert.False(t, response.IdempotentReplayed())
+
+	// The POST is retried with the generated key
+	sent := keys()
+	require.Len(t, sent, 2)
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, sent[0])
+	assert.Equal(t, sent[0], sent[1])
+
+	// Another request gets another key
+	response = client.PatchWithContext(t.Context(), "/users/1", &User{Name: "jane"})
+	require.NoError(t, response.Err)
+	sent = keys()
+	require.Len(t, sent, 4)
+	assert.NotEqual(t, sent[0], sent[2])
+	assert.Equal(t, sent[2], sent[3])
+}
+
+func TestIdempotency_ExplicitKey(t *testing.T) {
+	handler, keys := idempotentHandler()
+	srv := newServer(t, handler)
+	client := &rest.Client{BaseURL: srv.URL, Retry: &rest.Retry{Backoff: time.Millisecond}}
+
+	// An explicit key is sent without Client.Idempotency, and replayed by the server
+	ctx := rest.WithOptions(t.Context(), rest.WithIdempotencyKey("payment-42"))
+	response := client.PostWithContext(ctx, "/payments", &User{ID: 1})
+	require.NoError(t, response.Err)
+	assert.False(t, response.IdempotentReplayed())
+
+	response = client.R().IdempotencyKey("payment-42").Post(t.Context(), "/payments", &User{ID: 1})
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+	assert.True(t, response.IdempotentReplayed())
+	assert.Equal(t, []string{"payment-42", "payment-42", "payment-42"}, keys())
+
+	// Without a key, a POST is neither keyed nor retried
+	response = client.PostWithContext(t.Context(), "/payments", &User{ID: 1})
+	require.NoError(t, response.Err)
+	assert.Len(t, keys(), 4)
+}
+
+func TestIdempotency_Required(t *testing.T) {
+	handler, keys := idempotentHandler()
+	srv := newServer(t, handler)
+	client := &rest.Client{
+		BaseURL: srv.URL,
+		Idempotency: &rest.Idempotency{
+			Required: []string{"/payments", "/orders/{id}/refunds"},
+			NewKey:   func() string { return "generated" },
+		},
+	}
+
+	response := client.PostWithContext(t.Context(), "/payments", &User{ID: 1})
+	require.ErrorIs(t, response.Err, rest.ErrIdempotencyKeyRequired)
+	assert.ErrorContains(t, response.Err, "POST /payments")
+
+	ctx := rest.WithOptions(t.Context(), rest.WithPathParam("id", "7"))
+	response = client.PatchWithContext(ctx, "/orders/{id}/refunds", &User{ID: 1})
+	require.ErrorIs(t, response.Err, rest.ErrIdempotencyKeyRequired)
+	assert.ErrorContains(t, response.Err, "PATCH /orders/{id}/refunds")
+	assert.Empty(t, keys())
+
+	// Other routes and methods are not affected
+	response = client.PutWithContext(t.Context(), "/payments", &User{ID: 1})
+	require.NoError(t, response.Err)
+	response = client.PostWithContext(t.Context(), "/users", &User{ID: 1})
+	require.NoError(t, response.Err)
+	assert.Equal(t, []string{"", "generated"}, keys())
+}
+
+func TestIdempotency_Required_BasePath(t *testing.T) {
+	handler, keys := idempotentHandler()
+	srv := newServer(t, handler)
+	client := &rest.Client{
+		BaseURL: srv.URL + "/api/v1",
+		Idempotency: &rest.Idempotency{
+			Required: []string{"/payments"},
+			NewKey:   func() string { return "generated" },
+		},
+	}
+
+	// Routes are matched without the path of the base URL
+	response := client.PostWithContext(t.Context(), "/payments?currency=eur", &User{ID: 1})
+	require.ErrorIs(t, response.Err, rest.ErrIdempotencyKeyRequired)
+	assert.ErrorContains(t, response.Err, "POST /payments")
+
+	response = client.PostWithContext(t.Context(), "/users", &User{ID: 1})
+	require.NoError(t, response.Err)
+	assert.Equal(t, []string{"generated"}, keys())
+}
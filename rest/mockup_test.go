@@ -113,3 +113,24 @@ func TestFlushMockups(t *testing.T) {
 		t.Fatal("Mockup Should Be Removed!")
 	}
 }
+
+func TestMockup_Query(t *testing.T) {
+	defer rest.StopMockupServer()
+	rest.StartMockupServer()
+
+	// Mocks match on the first value of each query param, whatever their order
+	mock := rest.Mock{
+		URL:          "http://mytest.com/search?tag=x&q=a b",
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespBody:     "found",
+	}
+
+	err := rest.AddMockups(&mock)
+	require.NoError(t, err)
+
+	client := &rest.Client{}
+	response := client.R().Query("q", "a b").Query("tag", "x", "y").Get(t.Context(), "http://mytest.com/search")
+	require.NoError(t, response.Err)
+	require.Equal(t, "found", response.String())
+}
@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
 	"net/http/httptrace"
 	"net/url"
@@ -19,8 +18,8 @@ import (
 
 	"go.opentelemetry.io/contrib/instrumentation/net/http/httptrace/otelhttptrace"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/clientcredentials"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var Version = "1.0.0"
@@ -34,6 +33,12 @@ var (
 	// contentVerbs contains HTTP methods that typically include a request body.
 	contentVerbs = []string{http.MethodPost, http.MethodPut, http.MethodPatch}
 
+	// idempotentVerbs contains HTTP methods that can be sent again safely.
+	// These methods are eligible for retries.
+	idempotentVerbs = []string{
+		http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete,
+	}
+
 	// defaultCheckRedirectFunc is the default function used to handle HTTP redirects.
 	defaultCheckRedirectFunc func(request *http.Request, via []*http.Request) error
 )
@@ -83,6 +88,18 @@ const (
 
 	// IfNoneMatchHeader is the header name for the If-None-Match value.
 	IfNoneMatchHeader = "If-None-Match"
+
+	// IfMatchHeader is the header name for the If-Match value of conditional writes.
+	IfMatchHeader = "If-Match"
+
+	// LastEventIDHeader is the header name used to resume a Server-Sent Events stream.
+	LastEventIDHeader = "Last-Event-Id"
+
+	// AuthorizationHeader is the header name for the request credentials.
+	AuthorizationHeader = "Authorization"
+
+	// ProxyAuthorizationHeader is the header name for the proxy credentials.
+	ProxyAuthorizationHeader = "Proxy-Authorization"
 )
 
 // newRequest creates a new HTTP request and returns the response.
@@ -92,7 +109,7 @@ const (
 // Parameters:
 //   - ctx: The context for the request, which can be used for cancellation and tracing.
 //   - verb: The HTTP method to use (GET, POST, etc.).
-//   - apiURL: The URL to request, which will be appended to the client's BaseURL.
+//   - apiURL: The URL to request, which will be resolved against the client's BaseURL.
 //   - body: The request body, which will be marshaled according to the client's ContentType.
 //   - headers: Optional additional headers to include in the request.
 //
@@ -103,20 +120,44 @@ func (r *Client) newRequest(
 	apiURL string,
 	body any,
 	headers ...http.Header,
-) *Response {
-	validURL, err := url.Parse(fmt.Sprintf("%s%s", r.BaseURL, apiURL))
+) (response *Response) {
+	options := optionsFromContext(ctx)
+	timeout := effectiveTimeout(ctx, options.timeout)
+	ctx, cancel := applyOptions(ctx, options, apiURL)
+	defer cancel()
+
+	// Responses read from the cache are shared, so only fresh ones record their endpoint
+	var cacheResponse *Response
+	var hedged bool
+	target := r.pickEndpoint()
+	defer func() {
+		target.release()
+		if response != nil && response != cacheResponse {
+			if target != nil {
+				response.Endpoint = target.baseURL
+			}
+			response.Hedged = hedged
+		}
+	}()
+
+	requestURL, err := r.resolveURL(target.base(r), apiURL, options)
 	if err != nil {
 		return &Response{
 			Err: err,
 		}
 	}
 
-	apiURL = validURL.String()
+	// Resources are cached by their logical URL, whichever endpoint serves them
+	cacheURL, err := r.cacheURL(target, requestURL, apiURL, options)
+	if err != nil {
+		return &Response{
+			Err: err,
+		}
+	}
 
-	var cacheResponse *Response
 	// If Cache enable && operation is read: Cache GET
 	if r.EnableCache && slices.Contains(readVerbs, verb) {
-		if value, hit := resourceCache.get(apiURL); hit {
+		if value, hit := resourceCache.get(cacheURL); hit {
 			cacheResponse = value
 			if cacheResponse != nil {
 				cacheResponse.Hit()
@@ -127,48 +168,52 @@ func (r *Client) newRequest(
 		}
 	}
 
-	// Prepare contentReader for the body
-	contentReader, err := setContentReader(body, r.ContentType)
+	// Responses from the cache take no slot of the bulkhead
+	slot, err := r.Bulkhead.acquire(ctx)
 	if err != nil {
 		return &Response{
 			Err: err,
 		}
 	}
+	defer func() {
+		slot.release(response == nil || response.Err != nil)
+	}()
 
-	// Change URL to point to Mockup server
-	var cacheURL string
-	apiURL, cacheURL, err = checkMockup(apiURL)
-	if err != nil {
-		return &Response{
-			Err: err,
-		}
+	httpClient, request, err := r.prepareRequest(target.withContext(ctx), verb, requestURL, body,
+		cacheResponse, headers...)
+	if err == nil {
+		err = r.setIdempotencyKey(request, options, apiURL)
 	}
-
-	// Enable trace if enabled
-	if r.EnableTrace {
-		ctx = httptrace.WithClientTrace(ctx, otelhttptrace.NewClientTrace(ctx))
+	if err == nil {
+		err = setIfMatch(request, options, cacheURL)
 	}
-
-	// Create a new HTTP client
-	httpClient := r.newHTTPClient(ctx)
-
-	// Create a new HTTP request
-	request, err := http.NewRequestWithContext(ctx, verb, apiURL, contentReader)
 	if err != nil {
 		return &Response{
 			Err: err,
 		}
 	}
 
-	// Set extra parameters
-	r.setParams(request, cacheResponse, cacheURL, headers...)
-
-	// Make the request
-	httpResponse, err := httpClient.Do(request)
+	// Make the request, hedging reads when enabled
+	var httpResponse *http.Response
+	if r.Hedging != nil && slices.Contains(readVerbs, verb) && body == nil {
+		httpResponse, target, hedged, err = r.hedge(ctx, httpClient, request, target,
+			func(ctx context.Context, hedgeEndpoint *endpoint) (*http.Request, error) {
+				hedgeURL, hErr := r.resolveURL(hedgeEndpoint.base(r), apiURL, options)
+				if hErr != nil {
+					return nil, hErr
+				}
+				_, hedgeRequest, hErr := r.prepareRequest(hedgeEndpoint.withContext(ctx), verb, hedgeURL, nil,
+					cacheResponse, headers...)
+				return hedgeRequest, hErr
+			})
+	} else {
+		httpResponse, err = httpClient.Do(request)
+		target.observe(ctx, httpResponse, err)
+	}
 	// Error handling
 	if err != nil {
 		return &Response{
-			Err: err,
+			Err: requestTimeoutErr(ctx, timeout, err),
 		}
 	}
 	defer func(Body io.ReadCloser) {
@@ -191,17 +236,12 @@ func (r *Client) newRequest(
 	respBody, err := io.ReadAll(respReader)
 	if err != nil {
 		return &Response{
-			Err: err,
+			Err: requestTimeoutErr(ctx, timeout, err),
 		}
 	}
 
 	// Create a new response
-	response := &Response{
-		Response: httpResponse,
-		bytes:    respBody,
-	}
-
-	setProblem(response)
+	response = NewResponse(httpResponse, respBody)
 
 	// Cache headers
 	cacheHeaders := struct {
@@ -223,9 +263,82 @@ func (r *Client) newRequest(
 		resourceCache.setNX(cacheURL, response)
 	}
 
+	// A successful conditional write changed the resource, so its cached ETag is stale
+	if options.ifMatch != nil && response.IsOk() && slices.Contains(conditionalVerbs, verb) {
+		resourceCache.del(cacheURL)
+	}
+
 	return response
 }
 
+// resolveURL expands the path params of the given URL, resolves it against the base URL
+// (RFC 3986) and merges the query params of the request options.
+// It fails with any error recorded while applying the request options.
+func (r *Client) resolveURL(baseURL string, apiURL string, options *requestOptions) (string, error) {
+	if options.err != nil {
+		return "", options.err
+	}
+
+	validURL, err := joinURL(baseURL, options.expand(apiURL))
+	if err != nil {
+		return "", err
+	}
+
+	mergeQuery(validURL, options.query)
+
+	return validURL.String(), nil
+}
+
+// prepareRequest builds the outgoing HTTP request for the given verb and URL.
+// It marshals the body, redirects to the mockup server if enabled, enables tracing,
+// sets up the HTTP client and sets the request headers, but the Idempotency-Key and
+// If-Match ones, which depend on the request URL before resolution.
+//
+// Returns the HTTP client and the request.
+func (r *Client) prepareRequest(
+	ctx context.Context,
+	verb string,
+	apiURL string,
+	body any,
+	cacheResponse *Response,
+	headers ...http.Header,
+) (*http.Client, *http.Request, error) {
+	// Prepare contentReader for the body
+	contentReader, err := setContentReader(body, r.ContentType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Change URL to point to Mockup server
+	var originalURL string
+	apiURL, originalURL, err = checkMockup(apiURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	originalURL = r.socketURL(originalURL)
+
+	// Enable trace if enabled
+	if r.EnableTrace {
+		ctx = httptrace.WithClientTrace(ctx, otelhttptrace.NewClientTrace(ctx))
+	}
+
+	// Create a new HTTP client
+	httpClient := r.newHTTPClient(ctx)
+
+	// Create a new HTTP request
+	request, err := http.NewRequestWithContext(ctx, verb, apiURL, contentReader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Set extra parameters
+	r.setParams(request, cacheResponse, originalURL, headers...)
+	if document, ok := body.(patchDocument); ok {
+		request.Header.Set(CanonicalContentTypeHeader, document.contentType())
+	}
+	return httpClient, request, nil
+}
+
 // handleGZip checks if GZip compression is enabled for the given request and response.
 // Returns true if the response is gzip-encoded and the client is configured to handle it.
 func (r *Client) handleGZip(request *http.Request, response *http.Response) bool {
@@ -235,9 +348,14 @@ func (r *Client) handleGZip(request *http.Request, response *http.Response) bool
 
 // setContentReader creates a reader from the given body and content type.
 // It marshals the body according to the specified content type and returns an io.Reader.
+// JSONPatch and MergePatch bodies are always marshaled as JSON.
 // If body is nil, it returns http.NoBody.
 // Returns an error if the content type is not supported or if marshaling fails.
 func setContentReader(body any, contentType ContentType) (io.Reader, error) {
+	if document, ok := body.(patchDocument); ok {
+		return marshalPatch(document)
+	}
+
 	if body != nil {
 		mediaContent, found := contentMarshalers[contentType]
 		if !found {
@@ -323,22 +441,61 @@ func checkMockup(reqURL string) (string, string, error) {
 // The client is configured with:
 //   - Custom transport settings
 //   - OpenTelemetry tracing if enabled
-//   - OAuth2 client credentials if provided
+//   - OAuth2, Digest and Auth credentials if provided, renewed once on 401 Unauthorized
+//   - Request signing if a Signer is provided
+//   - Retries of transient failures if Retry is provided
 //   - Default headers
 //   - Redirect handling based on FollowRedirect setting
 //
 // Returns the configured http.Client.
-func (r *Client) newHTTPClient(ctx context.Context) *http.Client {
+func (r *Client) newHTTPClient(_ context.Context) *http.Client {
 	r.clientMtxOnce.Do(func() {
 		r.clientMtx.Lock()
 		defer r.clientMtx.Unlock()
 
-		tr := r.setupTransport()
+		var tr http.RoundTripper = &timeoutTransport{
+			Transport:      r.setupTransport(),
+			connectTimeout: r.getConnectionTimeout(),
+			headerTimeout:  r.getRequestTimeout(),
+		}
 		if r.EnableTrace {
-			tr = otelhttp.NewTransport(tr)
+			tr = otelhttp.NewTransport(
+				&routeTransport{Transport: tr},
+				otelhttp.WithSpanNameFormatter(spanName),
+				otelhttp.WithMetricAttributesFn(routeAttributes),
+			)
 		}
 		r.Client = &http.Client{Transport: tr}
 
+		// Requests are signed on every attempt, once their credentials are set
+		if r.Signer != nil {
+			r.Client.Transport = &signTransport{Transport: tr, signer: r.Signer}
+		}
+
+		// Credentials are added per request; OAuth tokens come from a single token
+		// source shared by every request and are requested without the authTransport
+		var providers chainAuth
+		if r.OAuth != nil {
+			providers = append(providers, &oauthAuth{source: r.OAuth.tokenSource(&http.Client{Transport: tr})})
+		}
+		if r.BasicAuth != nil && r.OAuth == nil {
+			providers = append(providers, r.BasicAuth)
+		}
+		if r.DigestAuth != nil {
+			providers = append(providers, r.DigestAuth)
+		}
+		if r.Auth != nil {
+			providers = append(providers, r.Auth)
+		}
+		if len(providers) > 0 {
+			r.Client.Transport = &authTransport{Transport: r.Client.Transport, auth: providers}
+		}
+
+		// Every retry is authenticated and signed again
+		if r.Retry != nil {
+			r.Client.Transport = &retryTransport{Transport: r.Client.Transport, retry: r.Retry, client: r}
+		}
+
 		// Redirect handling
 		if !r.FollowRedirect {
 			r.Client.CheckRedirect = func(_ *http.Request, _ []*http.Request) error {
@@ -362,20 +519,46 @@ func (r *Client) newHTTPClient(ctx context.Context) *http.Client {
 		}
 	})
 
-	if r.OAuth != nil {
-		oauthConfig := &clientcredentials.Config{
-			ClientID:       r.OAuth.ClientID,
-			ClientSecret:   r.OAuth.ClientSecret,
-			TokenURL:       r.OAuth.TokenURL,
-			AuthStyle:      oauth2.AuthStyle(r.OAuth.AuthStyle),
-			Scopes:         r.OAuth.Scopes,
-			EndpointParams: r.OAuth.EndpointParams,
-		}
+	return r.Client
+}
 
-		return oauthConfig.Client(context.WithValue(ctx, oauth2.HTTPClient, r.Client))
+// spanName names client spans after the route template when one is known,
+// e.g. "GET /users/{id}", falling back to "HTTP GET".
+func spanName(_ string, request *http.Request) string {
+	if route := RouteFromContext(request.Context()); route != "" {
+		return request.Method + " " + route
 	}
 
-	return r.Client
+	return "HTTP " + request.Method
+}
+
+// routeAttributes returns the http.route attribute for the request, if any.
+func routeAttributes(request *http.Request) []attribute.KeyValue {
+	if route := RouteFromContext(request.Context()); route != "" {
+		return []attribute.KeyValue{attribute.String("http.route", route)}
+	}
+
+	return nil
+}
+
+// routeTransport adds the route template of the request and the endpoint of
+// Client.BaseURLs it was sent to, if any, to the active client span.
+type routeTransport struct {
+	Transport http.RoundTripper
+}
+
+// RoundTrip sets the http.route and rest.endpoint attributes and delegates to the
+// wrapped transport.
+func (r *routeTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	span := trace.SpanFromContext(request.Context())
+	if attributes := routeAttributes(request); attributes != nil {
+		span.SetAttributes(attributes...)
+	}
+	if endpoint, ok := request.Context().Value(endpointKey{}).(string); ok {
+		span.SetAttributes(attribute.String("rest.endpoint", endpoint))
+	}
+
+	return r.Transport.RoundTrip(request)
 }
 
 // setupTransport sets up the HTTP transport for the client.
@@ -384,65 +567,107 @@ func (r *Client) newHTTPClient(ctx context.Context) *http.Client {
 // If a CustomPool is provided, it uses that for transport configuration.
 // Otherwise, it uses the default transport shared across all clients.
 //
+// The response header and connect timeouts are enforced per request by timeoutTransport,
+// so the shared transport is never modified for a single client.
+//
 // Returns the configured http.RoundTripper to use for HTTP requests.
 func (r *Client) setupTransport() http.RoundTripper {
-	timeout := r.getRequestTimeout()
+	// Endpoints are dialed by their host, never over a Unix domain socket
+	if slices.ContainsFunc(r.BaseURLs, func(baseURL string) bool {
+		return strings.HasPrefix(baseURL, unixScheme)
+	}) {
+		return &errTransport{err: errors.New("BaseURLs cannot be Unix domain sockets, use BaseURL")}
+	}
+
 	// If there's no CustomPool, use the default transport
 	if r.CustomPool == nil {
 		transportMtxOnce.Do(func() {
 			dfltTransport = &http.Transport{
 				MaxIdleConnsPerHost: http.DefaultMaxIdleConnsPerHost,
 				Proxy:               http.ProxyFromEnvironment,
-				DialContext:         r.getDialContext(),
+				// Shared by every client, so it dials without client settings
+				DialContext: new(Client).dialContext,
 			}
 			defaultCheckRedirectFunc = http.Client{}.CheckRedirect
 		})
 
-		// Use a local copy of the transport to avoid modifying the shared dfltTransport's
-		// ResponseHeaderTimeout, which could cause race conditions or incorrect timeouts
-		// for other clients sharing it.
-		if transport, ok := dfltTransport.(*http.Transport); ok {
-			tCopy := transport.Clone()
-			tCopy.ResponseHeaderTimeout = timeout
-			return tCopy
+		_, isUnix := r.unixSocket()
+		if r.TLS == nil && r.Proxy == nil && r.Dialer == nil && !isUnix {
+			return dfltTransport
 		}
-		return dfltTransport
+
+		// TLS, proxy and dialer settings need a transport of their own
+		return r.configureTransport(&http.Transport{
+			MaxIdleConnsPerHost: http.DefaultMaxIdleConnsPerHost,
+			Proxy:               http.ProxyFromEnvironment,
+			DialContext:         r.dialContext,
+		})
 	}
 
-	// If the CustomPool already has a transport, update timeouts if it's *http.Transport
+	// If the CustomPool already has a transport, dial with the per-request connect timeout
 	if transport, ok := r.CustomPool.Transport.(*http.Transport); ok {
-		transport.DialContext = r.getDialContext()
-		transport.ResponseHeaderTimeout = r.getRequestTimeout()
-		return transport
+		transport.DialContext = r.dialContext
+		return r.configureTransport(transport)
 	}
 
 	// Create a new custom transport if none is set yet
 	if r.CustomPool.Transport == nil {
 		transport := &http.Transport{
-			MaxIdleConnsPerHost:   r.CustomPool.MaxIdleConnsPerHost,
-			DialContext:           r.getDialContext(),
-			ResponseHeaderTimeout: r.getRequestTimeout(),
+			MaxIdleConnsPerHost: r.CustomPool.MaxIdleConnsPerHost,
+			DialContext:         r.dialContext,
 		}
 
 		// If a proxy is defined, parse and set it
 		if proxyURL := r.CustomPool.Proxy; proxyURL != "" {
-			if parsed, err := url.Parse(proxyURL); err == nil {
-				transport.Proxy = http.ProxyURL(parsed)
+			parsed, err := parseProxyURL(proxyURL)
+			if err != nil {
+				return &errTransport{err: err}
 			}
+			transport.Proxy = http.ProxyURL(parsed)
 		}
 
 		r.CustomPool.Transport = transport
-		return transport
+		return r.configureTransport(transport)
+	}
+
+	// If a non-http.Transport is already set, TLS and proxy settings cannot be applied
+	if r.TLS != nil || r.Proxy != nil {
+		return &errTransport{err: errors.New("TLS and Proxy require CustomPool.Transport to be an *http.Transport")}
 	}
 
-	// If a non-http.Transport is already set
 	return r.CustomPool.Transport
 }
 
-// getDialContext returns a context.DialContext function that applies the configured connection timeout.
-// This is used by the HTTP transport to establish network connections.
-func (r *Client) getDialContext() func(ctx context.Context, network string, address string) (net.Conn, error) {
-	return (&net.Dialer{Timeout: r.getConnectionTimeout()}).DialContext
+// configureTransport applies the proxy and TLS settings of the client to the transport.
+// Invalid settings, such as a missing certificate file or a malformed proxy URL, fail
+// every request of the client.
+func (r *Client) configureTransport(transport *http.Transport) http.RoundTripper {
+	if r.Proxy != nil {
+		proxy, err := r.Proxy.proxyFunc()
+		if err != nil {
+			return &errTransport{err: err}
+		}
+		transport.Proxy = proxy
+	}
+
+	if r.TLS != nil {
+		config, err := r.TLS.config(transport.TLSClientConfig)
+		if err != nil {
+			return &errTransport{err: err}
+		}
+		transport.TLSClientConfig = config
+
+		// Pinned connections are dialed knowing their host, unless the transport dials them
+		if len(r.TLS.Pins) > 0 && transport.DialTLSContext == nil {
+			dialTLS, dErr := r.TLS.dialTLSContext(transport)
+			if dErr != nil {
+				return &errTransport{err: dErr}
+			}
+			transport.DialTLSContext = dialTLS
+		}
+	}
+
+	return transport
 }
 
 // getRequestTimeout returns the configured request timeout duration.
@@ -511,7 +736,6 @@ func (r *Client) asyncNewRequest(
 // It configures various HTTP headers for the request, including:
 //   - Default headers (Connection, Cache-Control)
 //   - Mockup server headers if enabled
-//   - Authentication headers (Basic Auth)
 //   - User-Agent
 //   - Content negotiation headers (Accept, Content-Type)
 //   - Compression headers (Accept-Encoding)
@@ -533,11 +757,6 @@ func (r *Client) setParams(
 		request.Header.Set(XOriginalURLHeader, cacheURL)
 	}
 
-	// Basic Auth
-	if r.BasicAuth != nil && r.OAuth == nil {
-		request.SetBasicAuth(r.BasicAuth.Username, r.BasicAuth.Password)
-	}
-
 	// User Agent
 	request.Header.Set(UserAgentHeader, func() string {
 		if r.UserAgent != "" {
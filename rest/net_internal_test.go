@@ -100,10 +100,9 @@ func Test_setParams_headersAndOptions(t *testing.T) {
 		t.Errorf("X-Original-Url header expected when mock mode is active")
 	}
 
-	// Basic auth should be set when OAuth is nil
-	u, p, ok := req.BasicAuth()
-	if !ok || u != "u" || p != "p" {
-		t.Errorf("basic auth not set correctly")
+	// Basic auth is set by the authTransport, not with the request params
+	if _, _, ok := req.BasicAuth(); ok {
+		t.Errorf("basic auth not expected in request params")
 	}
 
 	// User-Agent
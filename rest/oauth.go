@@ -0,0 +1,244 @@
+package rest
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// tokenExpiryDelta is how long before its expiry a cached token is refreshed,
+// so that it does not expire while a request is in flight.
+const tokenExpiryDelta = 10 * time.Second
+
+// tokenFunc adapts a function to oauth2.TokenSource.
+type tokenFunc func() (*oauth2.Token, error)
+
+// Token returns a new token.
+func (f tokenFunc) Token() (*oauth2.Token, error) {
+	return f()
+}
+
+// tokenSource is a concurrency-safe token cache shared by every request of a Client.
+// The token is refreshed shortly before it expires, and concurrent callers wait
+// for a single refresh instead of each fetching their own token.
+type tokenSource struct {
+	source oauth2.TokenSource
+	token  *oauth2.Token
+	mtx    sync.Mutex
+}
+
+// newTokenSource returns a cached token source over the given source, which is
+// called every time a new token is needed.
+func newTokenSource(source oauth2.TokenSource) *tokenSource {
+	return &tokenSource{source: source}
+}
+
+// Token returns the cached token, fetching a new one when it is missing or about to expire.
+func (r *tokenSource) Token() (*oauth2.Token, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.token != nil && r.token.AccessToken != "" &&
+		(r.token.Expiry.IsZero() || time.Now().Add(tokenExpiryDelta).Before(r.token.Expiry)) {
+		return r.token, nil
+	}
+
+	token, err := r.source.Token()
+	if err != nil {
+		return nil, err
+	}
+	r.token = token
+
+	return token, nil
+}
+
+// invalidate drops the cached token if it is still the given access token, so the next
+// call to Token fetches a new one. A token already replaced by another request is kept.
+func (r *tokenSource) invalidate(accessToken string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.token != nil && r.token.AccessToken == accessToken {
+		r.token = nil
+	}
+}
+
+// refreshTokenSource returns a source that redeems the refresh token of the given token
+// every time it is called, keeping the new refresh token when the server rotates it.
+func refreshTokenSource(ctx context.Context, config *oauth2.Config, token *oauth2.Token) oauth2.TokenSource {
+	refreshToken := token.RefreshToken
+
+	return tokenFunc(func() (*oauth2.Token, error) {
+		if refreshToken == "" {
+			return nil, errors.New("oauth2: token expired and refresh token is not set")
+		}
+
+		// A token without access token is invalid, so the config source refreshes it
+		newToken, err := config.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+		if err != nil {
+			return nil, err
+		}
+		refreshToken = newToken.RefreshToken
+
+		return newToken, nil
+	})
+}
+
+// tokenSource returns the token source of the OAuth configuration: the given TokenSource
+// or the selected grant. Tokens are requested with httpClient, outside the context of any
+// single request, so that a cancelled request does not fail the refresh shared with others.
+func (r *OAuth) tokenSource(httpClient *http.Client) *tokenSource {
+	if r.TokenSource != nil {
+		if source, ok := r.TokenSource.(*tokenSource); ok {
+			return source
+		}
+		return newTokenSource(r.TokenSource)
+	}
+
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, httpClient)
+
+	// The config is kept, so the auth style detected on the first request is reused
+	config := &clientcredentials.Config{
+		ClientID:     r.ClientID,
+		ClientSecret: r.ClientSecret,
+		TokenURL:     r.TokenURL,
+		AuthStyle:    oauth2.AuthStyle(r.AuthStyle),
+		Scopes:       r.Scopes,
+	}
+
+	// Tokens are fetched one at a time under the lock of the tokenSource, keeping the new
+	// refresh token when the server rotates it
+	refreshToken := r.RefreshToken
+	return newTokenSource(tokenFunc(func() (*oauth2.Token, error) {
+		params, err := r.grantParams(ctx, refreshToken)
+		if err != nil {
+			return nil, err
+		}
+		config.EndpointParams = params
+
+		token, err := config.Token(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if r.Grant == GrantRefreshToken {
+			refreshToken = token.RefreshToken
+		}
+
+		return token, nil
+	}))
+}
+
+// grantParams returns the parameters of a token request of the selected grant, on top
+// of EndpointParams. The grant_type parameter overrides the client credentials one, and
+// the refresh token grant redeems the given refresh token.
+func (r *OAuth) grantParams(ctx context.Context, refreshToken string) (url.Values, error) {
+	params := make(url.Values, len(r.EndpointParams)+1)
+	for key, values := range r.EndpointParams {
+		params[key] = slices.Clone(values)
+	}
+
+	switch r.Grant {
+	case "", GrantClientCredentials:
+		return params, nil
+	case GrantRefreshToken:
+		if refreshToken == "" {
+			return nil, errors.New("oauth2: token expired and refresh token is not set")
+		}
+
+		params.Set("grant_type", string(GrantRefreshToken))
+		params.Set("refresh_token", refreshToken)
+	case GrantJWTBearer:
+		if r.Assertion == nil {
+			return nil, errors.New("oauth2: jwt bearer grant without assertion")
+		}
+
+		assertion, err := r.Assertion(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		params.Set("grant_type", string(GrantJWTBearer))
+		params.Set("assertion", assertion)
+	case GrantTokenExchange:
+		if r.TokenExchange == nil || r.TokenExchange.SubjectToken == nil {
+			return nil, errors.New("oauth2: token exchange grant without subject token")
+		}
+
+		if err := r.TokenExchange.params(ctx, params); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("oauth2: unsupported grant type: %s", r.Grant)
+	}
+
+	return params, nil
+}
+
+// params adds the parameters of the token exchange request to the given values.
+func (r *TokenExchange) params(ctx context.Context, params url.Values) error {
+	subjectToken, err := r.SubjectToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	params.Set("grant_type", string(GrantTokenExchange))
+	params.Set("subject_token", subjectToken)
+	params.Set("subject_token_type", cmp.Or(r.SubjectTokenType, TokenTypeAccessToken))
+
+	if r.ActorToken != nil {
+		actorToken, aErr := r.ActorToken(ctx)
+		if aErr != nil {
+			return aErr
+		}
+		params.Set("actor_token", actorToken)
+		params.Set("actor_token_type", cmp.Or(r.ActorTokenType, TokenTypeAccessToken))
+	}
+
+	if r.RequestedTokenType != "" {
+		params.Set("requested_token_type", r.RequestedTokenType)
+	}
+
+	for _, audience := range r.Audience {
+		params.Add("audience", audience)
+	}
+
+	for _, resource := range r.Resource {
+		params.Add("resource", resource)
+	}
+
+	return nil
+}
+
+// oauthAuth authenticates requests with the token of a shared token source.
+type oauthAuth struct {
+	source *tokenSource
+}
+
+// Authenticate sets the Authorization header with the current token.
+func (r *oauthAuth) Authenticate(request *http.Request) error {
+	token, err := r.source.Token()
+	if err != nil {
+		return err
+	}
+	token.SetAuthHeader(request)
+
+	return nil
+}
+
+// Renew invalidates the token of the rejected request, unless it has already been replaced.
+func (r *oauthAuth) Renew(request *http.Request) error {
+	_, accessToken, _ := strings.Cut(request.Header.Get(AuthorizationHeader), " ")
+	r.source.invalidate(accessToken)
+
+	return nil
+}
@@ -0,0 +1,344 @@
+package rest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arielsrv/go-restclient/rest"
+)
+
+// tokenEndpoint issues "token-1", "token-2", ... with the given lifetime in seconds, and
+// counts the tokens issued so far.
+func tokenEndpoint(t *testing.T, expiresIn int) (http.HandlerFunc, *atomic.Int32) {
+	var issued atomic.Int32
+	return func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": fmt.Sprintf("token-%d", issued.Add(1)),
+			"token_type":   "Bearer",
+			"expires_in":   expiresIn,
+		})
+	}, &issued
+}
+
+func TestOAuth_TokenReuse(t *testing.T) {
+	token, issued := tokenEndpoint(t, 3600)
+	tokenSrv := newServer(t, token)
+
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token-1", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	client := &rest.Client{
+		BaseURL: srv.URL,
+		OAuth: &rest.OAuth{
+			ClientID:     "client",
+			ClientSecret: "secret",
+			TokenURL:     tokenSrv.URL,
+			AuthStyle:    rest.AuthStyleInHeader,
+		},
+	}
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Go(func() {
+			response := client.GetWithContext(t.Context(), "/")
+			assert.NoError(t, response.Err)
+			assert.Equal(t, http.StatusOK, response.StatusCode)
+		})
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), issued.Load())
+}
+
+func TestOAuth_TokenExpired(t *testing.T) {
+	token, issued := tokenEndpoint(t, 1)
+	tokenSrv := newServer(t, token)
+
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	client := &rest.Client{
+		BaseURL: srv.URL,
+		OAuth:   &rest.OAuth{ClientID: "client", TokenURL: tokenSrv.URL},
+	}
+
+	for range 3 {
+		require.NoError(t, client.GetWithContext(t.Context(), "/").Err)
+	}
+
+	// Tokens about to expire are refreshed before each request
+	assert.Equal(t, int32(3), issued.Load())
+}
+
+func TestOAuth_AuthStyleDetectedOnce(t *testing.T) {
+	var requests, issued atomic.Int32
+	tokenSrv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		// Only credentials in the form are accepted
+		if _, _, ok := r.BasicAuth(); ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = fmt.Fprint(w, `{"error":"invalid_client"}`)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": fmt.Sprintf("token-%d", issued.Add(1)),
+			"token_type":   "Bearer",
+			"expires_in":   1,
+		})
+	}))
+
+	client := &rest.Client{
+		BaseURL: server.URL,
+		OAuth:   &rest.OAuth{ClientID: "client", ClientSecret: "secret", TokenURL: tokenSrv.URL},
+	}
+
+	for range 3 {
+		require.NoError(t, client.GetWithContext(t.Context(), "/user").Err)
+	}
+
+	// The header is only probed for the first token
+	assert.Equal(t, int32(3), issued.Load())
+	assert.Equal(t, int32(4), requests.Load())
+}
+
+func TestOAuth_RetryOnUnauthorized(t *testing.T) {
+	token, issued := tokenEndpoint(t, 3600)
+	tokenSrv := newServer(t, token)
+
+	var calls atomic.Int32
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"id":0,"name":"Maria"}`, string(body))
+
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	client := &rest.Client{
+		BaseURL:     srv.URL,
+		ContentType: rest.JSON,
+		OAuth:       &rest.OAuth{ClientID: "client", TokenURL: tokenSrv.URL},
+	}
+
+	response := client.PostWithContext(t.Context(), "/users", User{Name: "Maria"})
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+	assert.Equal(t, int32(2), issued.Load())
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestOAuth_RetryOnce(t *testing.T) {
+	token, issued := tokenEndpoint(t, 3600)
+	tokenSrv := newServer(t, token)
+
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+
+	client := &rest.Client{
+		BaseURL: srv.URL,
+		OAuth:   &rest.OAuth{ClientID: "client", TokenURL: tokenSrv.URL},
+	}
+
+	response := client.GetWithContext(t.Context(), "/")
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+	assert.Equal(t, int32(2), issued.Load())
+}
+
+func TestOAuth_TokenErr(t *testing.T) {
+	tokenSrv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+
+	client := &rest.Client{
+		BaseURL: server.URL,
+		OAuth:   &rest.OAuth{ClientID: "client", TokenURL: tokenSrv.URL},
+	}
+
+	response := client.GetWithContext(t.Context(), "/user")
+	require.Error(t, response.Err)
+}
+
+// grantEndpoint issues tokens with a rotated refresh token and the given lifetime in
+// seconds, and records the form of every token request.
+func grantEndpoint(t *testing.T, expiresIn int) (http.HandlerFunc, func() []url.Values) {
+	var (
+		mtx   sync.Mutex
+		forms []url.Values
+	)
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+
+		mtx.Lock()
+		forms = append(forms, r.PostForm)
+		n := len(forms)
+		mtx.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  fmt.Sprintf("token-%d", n),
+			"refresh_token": fmt.Sprintf("refresh-%d", n),
+			"token_type":    "Bearer",
+			"expires_in":    expiresIn,
+		})
+	}
+
+	return handler, func() []url.Values {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return slices.Clone(forms)
+	}
+}
+
+func TestOAuth_RefreshTokenGrant(t *testing.T) {
+	grant, forms := grantEndpoint(t, 1)
+	tokenSrv := newServer(t, grant)
+
+	client := &rest.Client{
+		BaseURL: server.URL,
+		OAuth: &rest.OAuth{
+			Grant:        rest.GrantRefreshToken,
+			ClientID:     "client",
+			TokenURL:     tokenSrv.URL,
+			AuthStyle:    rest.AuthStyleInParams,
+			RefreshToken: "stored",
+			EndpointParams: url.Values{
+				"audience": {"https://api.example.com"},
+			},
+		},
+	}
+
+	for range 2 {
+		require.NoError(t, client.GetWithContext(t.Context(), "/user").Err)
+	}
+
+	requests := forms()
+	require.Len(t, requests, 2)
+	assert.Equal(t, "refresh_token", requests[0].Get("grant_type"))
+	assert.Equal(t, "stored", requests[0].Get("refresh_token"))
+	// The rotated refresh token is used for the next refresh
+	assert.Equal(t, "refresh-1", requests[1].Get("refresh_token"))
+	for _, form := range requests {
+		assert.Equal(t, "https://api.example.com", form.Get("audience"))
+	}
+}
+
+func TestOAuth_JWTBearerGrant(t *testing.T) {
+	grant, forms := grantEndpoint(t, 3600)
+	tokenSrv := newServer(t, grant)
+
+	var assertions atomic.Int32
+	client := &rest.Client{
+		BaseURL: server.URL,
+		OAuth: &rest.OAuth{
+			Grant:     rest.GrantJWTBearer,
+			TokenURL:  tokenSrv.URL,
+			AuthStyle: rest.AuthStyleInParams,
+			Scopes:    []string{"read"},
+			Assertion: func(context.Context) (string, error) {
+				return fmt.Sprintf("jwt-%d", assertions.Add(1)), nil
+			},
+		},
+	}
+
+	for range 3 {
+		require.NoError(t, client.GetWithContext(t.Context(), "/user").Err)
+	}
+
+	requests := forms()
+	require.Len(t, requests, 1)
+	assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", requests[0].Get("grant_type"))
+	assert.Equal(t, "jwt-1", requests[0].Get("assertion"))
+	assert.Equal(t, "read", requests[0].Get("scope"))
+}
+
+func TestOAuth_TokenExchangeGrant(t *testing.T) {
+	grant, forms := grantEndpoint(t, 3600)
+	tokenSrv := newServer(t, grant)
+
+	client := &rest.Client{
+		BaseURL: server.URL,
+		OAuth: &rest.OAuth{
+			Grant:        rest.GrantTokenExchange,
+			ClientID:     "client",
+			ClientSecret: "secret",
+			TokenURL:     tokenSrv.URL,
+			AuthStyle:    rest.AuthStyleInHeader,
+			TokenExchange: &rest.TokenExchange{
+				SubjectToken: func(context.Context) (string, error) {
+					return "user-token", nil
+				},
+				ActorToken: func(context.Context) (string, error) {
+					return "service-token", nil
+				},
+				ActorTokenType:     rest.TokenTypeJWT,
+				RequestedTokenType: rest.TokenTypeAccessToken,
+				Audience:           []string{"orders"},
+			},
+		},
+	}
+
+	require.NoError(t, client.GetWithContext(t.Context(), "/user").Err)
+
+	requests := forms()
+	require.Len(t, requests, 1)
+	assert.Equal(t, url.Values{
+		"grant_type":           {"urn:ietf:params:oauth:grant-type:token-exchange"},
+		"subject_token":        {"user-token"},
+		"subject_token_type":   {rest.TokenTypeAccessToken},
+		"actor_token":          {"service-token"},
+		"actor_token_type":     {rest.TokenTypeJWT},
+		"requested_token_type": {rest.TokenTypeAccessToken},
+		"audience":             {"orders"},
+	}, requests[0])
+}
+
+func TestOAuth_GrantErr(t *testing.T) {
+	grant, forms := grantEndpoint(t, 3600)
+	tokenSrv := newServer(t, grant)
+
+	for _, oauth := range []*rest.OAuth{
+		{Grant: "password", TokenURL: tokenSrv.URL},
+		{Grant: rest.GrantJWTBearer, TokenURL: tokenSrv.URL},
+		{Grant: rest.GrantTokenExchange, TokenURL: tokenSrv.URL},
+		{Grant: rest.GrantRefreshToken, TokenURL: tokenSrv.URL},
+		{
+			Grant:    rest.GrantJWTBearer,
+			TokenURL: tokenSrv.URL,
+			Assertion: func(context.Context) (string, error) {
+				return "", errors.New("signing failed")
+			},
+		},
+	} {
+		client := &rest.Client{BaseURL: server.URL, OAuth: oauth}
+		require.Error(t, client.GetWithContext(t.Context(), "/user").Err)
+	}
+
+	assert.Empty(t, forms())
+}
@@ -0,0 +1,293 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PatchOp is the operation of a JSON Patch (RFC 6902) step.
+type PatchOp string
+
+// JSON Patch operations.
+const (
+	// PatchAdd adds Value at Path, inserting it into arrays ("-" appends).
+	PatchAdd PatchOp = "add"
+
+	// PatchRemove removes the value at Path.
+	PatchRemove PatchOp = "remove"
+
+	// PatchReplace replaces the value at Path with Value.
+	PatchReplace PatchOp = "replace"
+
+	// PatchMove moves the value at From to Path.
+	PatchMove PatchOp = "move"
+
+	// PatchCopy copies the value at From to Path.
+	PatchCopy PatchOp = "copy"
+
+	// PatchTest checks that the value at Path equals Value, failing the whole patch otherwise.
+	PatchTest PatchOp = "test"
+)
+
+// ErrMergePatchNotObject is returned by DiffMergePatch when a value is not a JSON object.
+var ErrMergePatchNotObject = errors.New("merge patch: values must be JSON objects")
+
+// JSONPatch is a JSON Patch document (RFC 6902): a list of operations applied in order,
+// atomically. As a request body it is marshaled to JSON and sent with the
+// application/json-patch+json Content-Type, whatever the ContentType of the client.
+//
+// Example usage:
+//
+//	patch := rest.JSONPatch{
+//	    {Op: rest.PatchTest, Path: "/version", Value: 3},
+//	    {Op: rest.PatchReplace, Path: "/name", Value: "jane"},
+//	    {Op: rest.PatchRemove, Path: "/tags/0"},
+//	}
+//	response := client.PatchWithContext(ctx, "/users/1", patch)
+type JSONPatch []PatchOperation
+
+// PatchOperation is a step of a JSONPatch. Paths are JSON Pointers (RFC 6901), such as
+// "/tags/0" or "/a~1b" for the key "a/b".
+type PatchOperation struct {
+	// Value is the value of add, replace and test operations.
+	Value any `json:"value,omitempty"`
+	// Op is the operation.
+	Op PatchOp `json:"op"`
+	// Path is the JSON Pointer of the target location.
+	Path string `json:"path"`
+	// From is the JSON Pointer of the source location of move and copy operations.
+	From string `json:"from,omitempty"`
+}
+
+// MarshalJSON encodes the operation, with a null Value for add, replace and test
+// operations with a nil Value.
+func (r PatchOperation) MarshalJSON() ([]byte, error) {
+	operation := struct {
+		Value *any    `json:"value,omitempty"`
+		Op    PatchOp `json:"op"`
+		Path  string  `json:"path"`
+		From  string  `json:"from,omitempty"`
+	}{Op: r.Op, Path: r.Path, From: r.From}
+	if r.Op == PatchAdd || r.Op == PatchReplace || r.Op == PatchTest {
+		operation.Value = &r.Value
+	}
+
+	return json.Marshal(operation)
+}
+
+// MergePatch is a JSON Merge Patch document (RFC 7396): the members to change of a JSON
+// object, nested objects being merged recursively and nil values removing members. As a
+// request body it is marshaled to JSON and sent with the application/merge-patch+json
+// Content-Type, whatever the ContentType of the client.
+//
+// Example usage:
+//
+//	patch := rest.MergePatch{
+//	    "name":    "jane",
+//	    "address": rest.MergePatch{"city": "Madrid"},
+//	    "phone":   nil, // removed
+//	}
+//	response := client.PatchWithContext(ctx, "/users/1", patch)
+type MergePatch map[string]any
+
+// patchDocument is a request body sent as JSON with its own Content-Type.
+type patchDocument interface {
+	contentType() string
+}
+
+// contentType returns the media type of JSON Patch documents.
+func (r JSONPatch) contentType() string {
+	return MIMEApplicationJSONPatch
+}
+
+// contentType returns the media type of JSON Merge Patch documents.
+func (r MergePatch) contentType() string {
+	return MIMEApplicationMergePatch
+}
+
+// DiffMergePatch returns the merge patch turning original into modified, both values
+// encoding to JSON objects, e.g. two versions of a struct. Arrays are replaced as a
+// whole, and members set to null in modified are removed, as merge patches cannot set
+// null values.
+//
+// Example usage:
+//
+//	patch, err := rest.DiffMergePatch(user, updated)
+//	if err != nil {
+//	    return err
+//	}
+//	response := client.PatchWithContext(ctx, "/users/1", patch)
+func DiffMergePatch(original, modified any) (MergePatch, error) {
+	source, target, err := decodeDiff(original, modified)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceObject, ok := source.(map[string]any)
+	targetObject, isObject := target.(map[string]any)
+	if !ok || !isObject {
+		return nil, ErrMergePatchNotObject
+	}
+
+	return diffMergePatch(sourceObject, targetObject), nil
+}
+
+// DiffJSONPatch returns the JSON Patch operations turning original into modified, e.g.
+// two versions of a struct: add, remove and replace operations, in a deterministic
+// order. Array elements are compared by index.
+//
+// Example usage:
+//
+//	patch, err := rest.DiffJSONPatch(user, updated)
+//	if err != nil {
+//	    return err
+//	}
+//	response := client.PatchWithContext(ctx, "/users/1", patch)
+func DiffJSONPatch(original, modified any) (JSONPatch, error) {
+	source, target, err := decodeDiff(original, modified)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := JSONPatch{}
+	diffJSONPatch(&patch, "", source, target)
+
+	return patch, nil
+}
+
+// decodeDiff round-trips both values through JSON, so that they are compared as the
+// server sees them.
+func decodeDiff(original, modified any) (any, any, error) {
+	source, err := decodeJSONValue(original)
+	if err != nil {
+		return nil, nil, fmt.Errorf("diff original: %w", err)
+	}
+
+	target, err := decodeJSONValue(modified)
+	if err != nil {
+		return nil, nil, fmt.Errorf("diff modified: %w", err)
+	}
+
+	return source, target, nil
+}
+
+// decodeJSONValue encodes the value to JSON and decodes it into maps, slices and
+// scalars, keeping numbers as written.
+func decodeJSONValue(value any) (any, error) {
+	reader, err := jsonMedia.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber()
+
+	var decoded any
+	if err = decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+
+	return decoded, nil
+}
+
+// diffMergePatch returns the merge patch between two decoded JSON objects.
+func diffMergePatch(source, target map[string]any) MergePatch {
+	patch := MergePatch{}
+	for key := range source {
+		if value, found := target[key]; !found || value == nil {
+			patch[key] = nil
+		}
+	}
+
+	for key, value := range target {
+		previous, found := source[key]
+		switch {
+		case value == nil || (found && reflect.DeepEqual(previous, value)):
+		case isJSONObject(previous) && isJSONObject(value):
+			patch[key] = diffMergePatch(previous.(map[string]any), value.(map[string]any))
+		default:
+			patch[key] = value
+		}
+	}
+
+	return patch
+}
+
+// diffJSONPatch appends to patch the operations turning the decoded JSON value source
+// into target, at the JSON Pointer path.
+func diffJSONPatch(patch *JSONPatch, path string, source, target any) {
+	if reflect.DeepEqual(source, target) {
+		return
+	}
+
+	switch sourceValue := source.(type) {
+	case map[string]any:
+		targetValue, ok := target.(map[string]any)
+		if !ok {
+			break
+		}
+
+		for _, key := range slices.Sorted(maps.Keys(sourceValue)) {
+			if _, found := targetValue[key]; !found {
+				*patch = append(*patch, PatchOperation{Op: PatchRemove, Path: path + "/" + escapePointer(key)})
+			}
+		}
+		for _, key := range slices.Sorted(maps.Keys(targetValue)) {
+			child := path + "/" + escapePointer(key)
+			if previous, found := sourceValue[key]; found {
+				diffJSONPatch(patch, child, previous, targetValue[key])
+			} else {
+				*patch = append(*patch, PatchOperation{Op: PatchAdd, Path: child, Value: targetValue[key]})
+			}
+		}
+		return
+	case []any:
+		targetValue, ok := target.([]any)
+		if !ok {
+			break
+		}
+
+		common := min(len(sourceValue), len(targetValue))
+		for index := range common {
+			diffJSONPatch(patch, path+"/"+strconv.Itoa(index), sourceValue[index], targetValue[index])
+		}
+		// Trailing elements are removed from the last, so that indexes stay valid
+		for index := len(sourceValue) - 1; index >= common; index-- {
+			*patch = append(*patch, PatchOperation{Op: PatchRemove, Path: path + "/" + strconv.Itoa(index)})
+		}
+		for _, value := range targetValue[common:] {
+			*patch = append(*patch, PatchOperation{Op: PatchAdd, Path: path + "/-", Value: value})
+		}
+		return
+	}
+
+	*patch = append(*patch, PatchOperation{Op: PatchReplace, Path: path, Value: target})
+}
+
+// isJSONObject reports whether the decoded JSON value is an object.
+func isJSONObject(value any) bool {
+	_, ok := value.(map[string]any)
+	return ok
+}
+
+// escapePointer escapes a member name as a JSON Pointer reference token (RFC 6901).
+func escapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+// marshalPatch encodes a patch document as JSON, whatever the ContentType of the client.
+func marshalPatch(document patchDocument) (*bytes.Buffer, error) {
+	b, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.NewBuffer(b), nil
+}
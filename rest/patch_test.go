@@ -0,0 +1,173 @@
+package rest_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arielsrv/go-restclient/rest"
+)
+
+type Address struct {
+	City   string `json:"city"`
+	Street string `json:"street,omitempty"`
+}
+
+type Profile struct {
+	Address *Address       `json:"address,omitempty"`
+	Labels  map[string]any `json:"labels,omitempty"`
+	Name    string         `json:"name"`
+	Tags    []string       `json:"tags,omitempty"`
+	Age     int            `json:"age,omitempty"`
+}
+
+func TestPatch_ContentType(t *testing.T) {
+	tests := []struct {
+		body        any
+		name        string
+		contentType string
+		expected    string
+	}{
+		{
+			name: "json patch",
+			body: rest.JSONPatch{
+				{Op: rest.PatchReplace, Path: "/name", Value: "jane"},
+				{Op: rest.PatchAdd, Path: "/nickname"},
+			},
+			contentType: rest.MIMEApplicationJSONPatch,
+			expected:    `[{"op":"replace","path":"/name","value":"jane"},{"op":"add","path":"/nickname","value":null}]`,
+		},
+		{
+			name:        "merge patch",
+			body:        rest.MergePatch{"name": "jane", "address": rest.MergePatch{"city": "Madrid"}, "age": nil},
+			contentType: rest.MIMEApplicationMergePatch,
+			expected:    `{"name":"jane","address":{"city":"Madrid"},"age":null}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var contentType, body string
+			srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				contentType = r.Header.Get("Content-Type")
+				b, _ := io.ReadAll(r.Body)
+				body = string(b)
+				w.WriteHeader(http.StatusNoContent)
+			}))
+
+			// The client content type does not apply to patch documents
+			client := &rest.Client{BaseURL: srv.URL, ContentType: rest.XML}
+
+			response := client.PatchWithContext(t.Context(), "/users/1", tt.body)
+			require.NoError(t, response.Err)
+			assert.Equal(t, http.StatusNoContent, response.StatusCode)
+			assert.Equal(t, tt.contentType, contentType)
+			assert.JSONEq(t, tt.expected, body)
+		})
+	}
+}
+
+func TestDiffMergePatch(t *testing.T) {
+	original := Profile{
+		Name:    "john",
+		Age:     30,
+		Tags:    []string{"admin"},
+		Address: &Address{City: "Buenos Aires", Street: "Corrientes"},
+		Labels:  map[string]any{"team": "sre", "tier": nil},
+	}
+	modified := Profile{
+		Name:    "john",
+		Tags:    []string{"admin", "ops"},
+		Address: &Address{City: "Madrid", Street: "Corrientes"},
+		Labels:  map[string]any{"team": "sre", "tier": 1},
+	}
+
+	patch, err := rest.DiffMergePatch(original, modified)
+	require.NoError(t, err)
+
+	b, err := json.Marshal(patch)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"age":null,"tags":["admin","ops"],"address":{"city":"Madrid"},"labels":{"tier":1}}`, string(b))
+
+	patch, err = rest.DiffMergePatch(original, original)
+	require.NoError(t, err)
+	assert.Empty(t, patch)
+
+	_, err = rest.DiffMergePatch([]string{"a"}, []string{"b"})
+	require.ErrorIs(t, err, rest.ErrMergePatchNotObject)
+}
+
+func TestDiffJSONPatch(t *testing.T) {
+	tests := []struct {
+		original any
+		modified any
+		name     string
+		expected string
+	}{
+		{
+			name:     "members",
+			original: Profile{Name: "john", Age: 30, Address: &Address{City: "Buenos Aires"}},
+			modified: Profile{
+				Name:    "jane",
+				Tags:    []string{"ops"},
+				Address: &Address{City: "Buenos Aires", Street: "Corrientes"},
+			},
+			expected: `[
+				{"op":"remove","path":"/age"},
+				{"op":"add","path":"/address/street","value":"Corrientes"},
+				{"op":"replace","path":"/name","value":"jane"},
+				{"op":"add","path":"/tags","value":["ops"]}
+			]`,
+		},
+		{
+			name:     "arrays",
+			original: []any{"a", "b", "c", map[string]any{"x": 1}},
+			modified: []any{"a", "B"},
+			expected: `[
+				{"op":"replace","path":"/1","value":"B"},
+				{"op":"remove","path":"/3"},
+				{"op":"remove","path":"/2"}
+			]`,
+		},
+		{
+			name:     "appended",
+			original: map[string]any{"a/b": []int{1}, "m~n": 1},
+			modified: map[string]any{"a/b": []int{1, 2, 3}, "m~n": "one"},
+			expected: `[
+				{"op":"add","path":"/a~1b/-","value":2},
+				{"op":"add","path":"/a~1b/-","value":3},
+				{"op":"replace","path":"/m~0n","value":"one"}
+			]`,
+		},
+		{
+			name:     "root",
+			original: map[string]any{"a": 1},
+			modified: []int{1},
+			expected: `[{"op":"replace","path":"","value":[1]}]`,
+		},
+		{
+			name:     "equal",
+			original: Profile{Name: "john"},
+			modified: map[string]any{"name": "john"},
+			expected: `[]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := rest.DiffJSONPatch(tt.original, tt.modified)
+			require.NoError(t, err)
+
+			b, err := json.Marshal(patch)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(b))
+		})
+	}
+
+	_, err := rest.DiffJSONPatch(make(chan int), nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,209 @@
+package rest
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// DefaultCallbackPath is the path of the loopback redirect URI used by PKCE.Authorize
+// when the config has no RedirectURL.
+const DefaultCallbackPath = "/callback"
+
+// ErrInvalidState is reported to the browser when the state of an authorization response
+// does not match the one sent, e.g. on a forged redirect. PKCE.Authorize ignores such
+// responses and keeps waiting for the real one.
+var ErrInvalidState = errors.New("oauth2: invalid authorization state")
+
+// PKCE runs the OAuth2 authorization code flow with Proof Key for Code Exchange
+// (RFC 7636), for public clients such as CLI tools that cannot keep a client secret.
+// The resulting token source refreshes itself with the refresh token and plugs into
+// a Client through OAuth.TokenSource.
+//
+// Example usage:
+//
+//	pkce := rest.NewPKCE(&oauth2.Config{
+//	    ClientID: "my-cli",
+//	    Endpoint: oauth2.Endpoint{
+//	        AuthURL:  "https://idp.example.com/authorize",
+//	        TokenURL: "https://idp.example.com/token",
+//	    },
+//	    Scopes: []string{"openid", "offline_access"},
+//	})
+//
+//	tokenSource, err := pkce.Authorize(ctx, func(authURL string) error {
+//	    fmt.Println("Open this URL in your browser:", authURL)
+//	    return nil
+//	})
+//	if err != nil {
+//	    return err
+//	}
+//
+//	client := &rest.Client{
+//	    BaseURL: "https://api.example.com",
+//	    OAuth:   &rest.OAuth{TokenSource: tokenSource},
+//	}
+type PKCE struct {
+	// Config is the OAuth2 client configuration: ClientID, Endpoint, Scopes and, optionally,
+	// a loopback RedirectURL such as "http://127.0.0.1:8085/callback".
+	Config *oauth2.Config
+	// Verifier is the code verifier. Its S256 challenge is sent with the authorization request.
+	Verifier string
+	// State is the opaque value binding the authorization response to this flow.
+	State string
+}
+
+// NewPKCE returns a PKCE flow with a new random code verifier and state.
+func NewPKCE(config *oauth2.Config) *PKCE {
+	return &PKCE{
+		Config:   config,
+		Verifier: oauth2.GenerateVerifier(),
+		State:    rand.Text(),
+	}
+}
+
+// Challenge returns the S256 code challenge of the verifier.
+func (r *PKCE) Challenge() string {
+	return oauth2.S256ChallengeFromVerifier(r.Verifier)
+}
+
+// AuthCodeURL returns the URL of the authorization endpoint, with the state and the
+// S256 code challenge.
+func (r *PKCE) AuthCodeURL(opts ...oauth2.AuthCodeOption) string {
+	opts = append([]oauth2.AuthCodeOption{oauth2.S256ChallengeOption(r.Verifier)}, opts...)
+
+	return r.Config.AuthCodeURL(r.State, opts...)
+}
+
+// Exchange redeems the authorization code, proving possession of the verifier, and
+// returns a token source that starts with the issued token and refreshes it with the
+// refresh token. The HTTP client set in ctx with oauth2.HTTPClient, if any, is used
+// for every token request.
+func (r *PKCE) Exchange(ctx context.Context, code string, opts ...oauth2.AuthCodeOption) (oauth2.TokenSource, error) {
+	opts = append([]oauth2.AuthCodeOption{oauth2.VerifierOption(r.Verifier)}, opts...)
+
+	token, err := r.Config.Exchange(ctx, code, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	source := newTokenSource(refreshTokenSource(context.WithoutCancel(ctx), r.Config, token))
+	source.token = token
+
+	return source, nil
+}
+
+// Authorize runs the whole flow for a CLI tool: it listens on a loopback redirect URI
+// (RFC 8252), calls open with the authorization URL so the user can grant access in a
+// browser, waits for the redirect and exchanges the code.
+//
+// The listener uses the host and port of Config.RedirectURL, or a random port on
+// 127.0.0.1 and DefaultCallbackPath when it is empty, and only answers on that exact
+// path. The configured RedirectURL is sent as is, as providers match it exactly, unless
+// its port is 0 and the listener picks one. Redirects with another state are ignored, so
+// a stray or forged request cannot abort the flow. The flow ends when ctx is done.
+func (r *PKCE) Authorize(ctx context.Context, open func(authURL string) error) (oauth2.TokenSource, error) {
+	redirectURL := &url.URL{Scheme: "http", Host: "127.0.0.1:0", Path: DefaultCallbackPath}
+	if r.Config.RedirectURL != "" {
+		var err error
+		if redirectURL, err = url.Parse(r.Config.RedirectURL); err != nil {
+			return nil, err
+		}
+	}
+	path := cmp.Or(redirectURL.Path, "/")
+
+	listener, err := (&net.ListenConfig{}).Listen(ctx, "tcp", redirectURL.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	// The redirect URI of the listener, used by both the authorization and the exchange
+	config := *r.Config
+	if redirectURL.Port() == "0" {
+		_, port, _ := net.SplitHostPort(listener.Addr().String())
+		redirectURL.Host = net.JoinHostPort(redirectURL.Hostname(), port)
+		config.RedirectURL = redirectURL.String()
+	}
+	flow := &PKCE{Config: &config, Verifier: r.Verifier, State: r.State}
+
+	results := make(chan callbackResult, 1)
+	callback := flow.callback(results)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		if request.URL.Path != path {
+			http.NotFound(w, request)
+			return
+		}
+		callback(w, request)
+	})
+
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Close()
+
+	if err = open(flow.AuthCodeURL()); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-results:
+		if result.err != nil {
+			return nil, result.err
+		}
+		return flow.Exchange(ctx, result.code)
+	}
+}
+
+// callbackResult is the outcome of the authorization redirect.
+type callbackResult struct {
+	err  error
+	code string
+}
+
+// callback handles the authorization redirect and reports its outcome once. Redirects
+// with another state are not reported.
+func (r *PKCE) callback(results chan<- callbackResult) http.HandlerFunc {
+	return func(w http.ResponseWriter, request *http.Request) {
+		query := request.URL.Query()
+		if subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(r.State)) != 1 {
+			http.Error(w, ErrInvalidState.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result callbackResult
+		switch {
+		case query.Get("error") != "":
+			result.err = fmt.Errorf("oauth2: authorization failed: %s %s",
+				query.Get("error"), query.Get("error_description"))
+		case query.Get("code") == "":
+			result.err = errors.New("oauth2: authorization response without code")
+		default:
+			result.code = query.Get("code")
+		}
+
+		select {
+		case results <- result:
+		default:
+		}
+
+		w.Header().Set(CanonicalContentTypeHeader, MIMETextPlain)
+		if result.err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprintln(w, "Authorization failed, you can close this window.")
+			return
+		}
+		_, _ = fmt.Fprintln(w, "Authorization complete, you can close this window.")
+	}
+}
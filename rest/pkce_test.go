@@ -0,0 +1,274 @@
+package rest_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/arielsrv/go-restclient/rest"
+)
+
+// authorizationServer is an in-process stand-in for an OAuth2 authorization server
+// supporting the authorization code grant with PKCE and the refresh token grant.
+type authorizationServer struct {
+	*httptest.Server
+	challenges map[string]string
+	redirects  map[string]string
+	issued     atomic.Int32
+	mtx        sync.Mutex
+}
+
+func newAuthorizationServer(t *testing.T) *authorizationServer {
+	t.Helper()
+
+	srv := &authorizationServer{
+		challenges: make(map[string]string),
+		redirects:  make(map[string]string),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		assert.Equal(t, "code", query.Get("response_type"))
+		assert.Equal(t, "S256", query.Get("code_challenge_method"))
+
+		srv.mtx.Lock()
+		code := fmt.Sprintf("code-%d", len(srv.challenges))
+		srv.challenges[code] = query.Get("code_challenge")
+		srv.redirects[code] = query.Get("redirect_uri")
+		srv.mtx.Unlock()
+
+		redirect, err := url.Parse(query.Get("redirect_uri"))
+		assert.NoError(t, err)
+		redirect.RawQuery = url.Values{"code": {code}, "state": {query.Get("state")}}.Encode()
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+
+		switch r.PostForm.Get("grant_type") {
+		case "authorization_code":
+			code := r.PostForm.Get("code")
+			srv.mtx.Lock()
+			challenge, redirect := srv.challenges[code], srv.redirects[code]
+			srv.mtx.Unlock()
+			if oauth2.S256ChallengeFromVerifier(r.PostForm.Get("code_verifier")) != challenge ||
+				r.PostForm.Get("redirect_uri") != redirect {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = fmt.Fprint(w, `{"error":"invalid_grant"}`)
+				return
+			}
+		case "refresh_token":
+			assert.NotEmpty(t, r.PostForm.Get("refresh_token"))
+		default:
+			t.Errorf("unexpected grant type: %s", r.PostForm.Get("grant_type"))
+		}
+
+		n := srv.issued.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  fmt.Sprintf("token-%d", n),
+			"refresh_token": fmt.Sprintf("refresh-%d", n),
+			"token_type":    "Bearer",
+			"expires_in":    3600,
+		})
+	})
+
+	srv.Server = newServer(t, mux)
+
+	return srv
+}
+
+func (r *authorizationServer) config() *oauth2.Config {
+	return &oauth2.Config{
+		ClientID: "cli",
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   r.URL + "/authorize",
+			TokenURL:  r.URL + "/token",
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+		Scopes: []string{"read"},
+	}
+}
+
+// browse follows the authorization URL, as the user's browser would.
+func browse(authURL string) error {
+	request, err := http.NewRequestWithContext(context.Background(), http.MethodGet, authURL, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+func TestPKCE_AuthCodeURL(t *testing.T) {
+	pkce := rest.NewPKCE(&oauth2.Config{
+		ClientID:    "cli",
+		RedirectURL: "http://127.0.0.1:8085/callback",
+		Endpoint:    oauth2.Endpoint{AuthURL: "https://idp.example.com/authorize"},
+	})
+	require.NotEmpty(t, pkce.Verifier)
+	require.NotEmpty(t, pkce.State)
+
+	authURL, err := url.Parse(pkce.AuthCodeURL())
+	require.NoError(t, err)
+
+	query := authURL.Query()
+	assert.Equal(t, pkce.State, query.Get("state"))
+	assert.Equal(t, pkce.Challenge(), query.Get("code_challenge"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	assert.Equal(t, "http://127.0.0.1:8085/callback", query.Get("redirect_uri"))
+}
+
+func TestPKCE_Authorize(t *testing.T) {
+	authSrv := newAuthorizationServer(t)
+
+	var calls atomic.Int32
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		// The first token is revoked, so the client refreshes it on 401
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tokenSource, err := rest.NewPKCE(authSrv.config()).Authorize(t.Context(), browse)
+	require.NoError(t, err)
+
+	token, err := tokenSource.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token.AccessToken)
+
+	client := &rest.Client{
+		BaseURL: srv.URL,
+		OAuth:   &rest.OAuth{TokenSource: tokenSource},
+	}
+
+	response := client.GetWithContext(t.Context(), "/")
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, int32(2), authSrv.issued.Load())
+
+	response = client.GetWithContext(t.Context(), "/")
+	require.NoError(t, response.Err)
+	assert.Equal(t, int32(2), authSrv.issued.Load())
+}
+
+func TestPKCE_Authorize_RedirectURL(t *testing.T) {
+	authSrv := newAuthorizationServer(t)
+
+	listener, err := (&net.ListenConfig{}).Listen(t.Context(), "tcp", "localhost:0")
+	require.NoError(t, err)
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	require.NoError(t, listener.Close())
+
+	authorize := func(t *testing.T, redirectURL string) *url.URL {
+		config := authSrv.config()
+		config.RedirectURL = redirectURL
+
+		var sent string
+		tokenSource, err := rest.NewPKCE(config).Authorize(t.Context(), func(authURL string) error {
+			parsed, err := url.Parse(authURL)
+			if err != nil {
+				return err
+			}
+			sent = parsed.Query().Get("redirect_uri")
+			return browse(authURL)
+		})
+		// The code exchange succeeds, sending the same redirect URI
+		require.NoError(t, err)
+		require.NotNil(t, tokenSource)
+
+		redirect, err := url.Parse(sent)
+		require.NoError(t, err)
+		return redirect
+	}
+
+	t.Run("registered", func(t *testing.T) {
+		registered := "http://localhost:" + port + "/callback?client=cli"
+		assert.Equal(t, registered, authorize(t, registered).String())
+	})
+
+	t.Run("random port", func(t *testing.T) {
+		redirect := authorize(t, "http://localhost:0/callback?client=cli")
+		assert.Equal(t, "localhost", redirect.Hostname())
+		assert.NotEqual(t, "0", redirect.Port())
+		assert.Equal(t, "/callback?client=cli", redirect.RequestURI())
+	})
+}
+
+func TestPKCE_Authorize_InvalidState(t *testing.T) {
+	authSrv := newAuthorizationServer(t)
+
+	pkce := rest.NewPKCE(authSrv.config())
+	tokenSource, err := pkce.Authorize(t.Context(), func(authURL string) error {
+		forged, err := url.Parse(authURL)
+		if err != nil {
+			return err
+		}
+		query := forged.Query()
+		query.Set("state", "forged")
+		forged.RawQuery = query.Encode()
+
+		// Forged redirects and requests to other paths are rejected without ending the flow
+		assert.ErrorContains(t, browse(forged.String()), "400")
+		redirect, err := url.Parse(query.Get("redirect_uri"))
+		if err != nil {
+			return err
+		}
+		redirect.Path += "/other"
+		assert.ErrorContains(t, browse(redirect.String()), "404")
+
+		return browse(authURL)
+	})
+	require.NoError(t, err)
+
+	token, err := tokenSource.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token.AccessToken)
+}
+
+func TestPKCE_Authorize_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+
+	pkce := rest.NewPKCE(&oauth2.Config{ClientID: "cli"})
+	_, err := pkce.Authorize(ctx, func(string) error {
+		cancel()
+		return nil
+	})
+
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestPKCE_Exchange_Err(t *testing.T) {
+	authSrv := newAuthorizationServer(t)
+
+	_, err := rest.NewPKCE(authSrv.config()).Exchange(t.Context(), "unknown")
+	require.Error(t, err)
+}
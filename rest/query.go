@@ -0,0 +1,272 @@
+package rest
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// queryTag is the struct tag read by EncodeQuery.
+const queryTag = "query"
+
+// layoutTag is the struct tag holding the time.Format layout of a time.Time field.
+const layoutTag = "layout"
+
+// textMarshalerType is the reflect.Type of encoding.TextMarshaler.
+var textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+
+// timeType is the reflect.Type of time.Time.
+var timeType = reflect.TypeFor[time.Time]()
+
+// EncodeQuery encodes the exported fields of a struct, or a pointer to one, as query
+// parameters, following the `query` struct tag:
+//
+//	type ListUsers struct {
+//	    Status  []string  `query:"status"`              // status=a&status=b
+//	    Fields  []string  `query:"fields,comma"`        // fields=a,b
+//	    Since   time.Time `query:"since" layout:"2006-01-02"`
+//	    Until   time.Time `query:"until,unix"`          // seconds since epoch
+//	    Page    *int      `query:"page"`                // skipped when nil
+//	    Search  string    `query:"q,omitempty"`
+//	    Ignored string    `query:"-"`
+//	    Paging                                          // embedded fields are flattened
+//	}
+//
+// Fields without a tag use the field name. Values implementing encoding.TextMarshaler
+// are encoded with MarshalText; time.Time uses the layout tag, RFC 3339 by default.
+// Nil pointers, empty slices and, with omitempty, zero values are skipped.
+func EncodeQuery(v any) (url.Values, error) {
+	values := make(url.Values)
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return values, nil
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("query encode fail, unsupported type: %T", v)
+	}
+
+	if err := encodeStruct(values, rv); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// WithQueryStruct adds the query parameters encoded by EncodeQuery from v to the request URL.
+// An encoding error is reported in Response.Err.
+func WithQueryStruct(v any) RequestOption {
+	return func(options *requestOptions) {
+		values, err := EncodeQuery(v)
+		if err != nil {
+			options.err = errors.Join(options.err, err)
+			return
+		}
+
+		if options.query == nil {
+			options.query = make(url.Values)
+		}
+		for key, value := range values {
+			options.query[key] = append(options.query[key], value...)
+		}
+	}
+}
+
+// encodeStruct adds the fields of the struct rv to values.
+func encodeStruct(values url.Values, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		tag := field.Tag.Get(queryTag)
+		if tag == "-" {
+			continue
+		}
+
+		fv := rv.Field(i)
+		name, opts, _ := strings.Cut(tag, ",")
+
+		// Embedded structs without an explicit name are flattened, as encoding/json does:
+		// unexported ones too, unless embedded through a pointer
+		if field.Anonymous && name == "" && (field.IsExported() || field.Type.Kind() == reflect.Struct) {
+			for fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					break
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct && !implementsTextMarshaler(fv) {
+				if err := encodeStruct(values, fv); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		if err := encodeField(values, name, fv, field.Tag.Get(layoutTag), tagOptions(opts)); err != nil {
+			return fmt.Errorf("query encode fail, field %s: %w", field.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// encodeField adds the value of a single struct field to values.
+func encodeField(values url.Values, name string, fv reflect.Value, layout string, opts tagOptions) error {
+	for fv.Kind() == reflect.Pointer || fv.Kind() == reflect.Interface {
+		if fv.IsNil() {
+			return nil
+		}
+		fv = fv.Elem()
+	}
+
+	if opts.has("omitempty") && fv.IsZero() {
+		return nil
+	}
+
+	if (fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array) && !implementsTextMarshaler(fv) {
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Uint8 {
+			values.Add(name, string(fv.Bytes()))
+			return nil
+		}
+
+		items := make([]string, 0, fv.Len())
+		for i := range fv.Len() {
+			item, ok, err := formatValue(fv.Index(i), layout, opts)
+			if err != nil {
+				return err
+			}
+			if ok {
+				items = append(items, item)
+			}
+		}
+
+		if len(items) == 0 {
+			return nil
+		}
+
+		if opts.has("comma") {
+			values.Add(name, strings.Join(items, ","))
+			return nil
+		}
+
+		values[name] = append(values[name], items...)
+		return nil
+	}
+
+	value, ok, err := formatValue(fv, layout, opts)
+	if err != nil || !ok {
+		return err
+	}
+
+	values.Add(name, value)
+
+	return nil
+}
+
+// formatValue formats a scalar value. It reports false for nil pointers.
+func formatValue(fv reflect.Value, layout string, opts tagOptions) (string, bool, error) {
+	for fv.Kind() == reflect.Pointer || fv.Kind() == reflect.Interface {
+		if fv.IsNil() {
+			return "", false, nil
+		}
+		fv = fv.Elem()
+	}
+
+	if fv.Type() == timeType {
+		t, _ := reflect.TypeAssert[time.Time](fv)
+		switch {
+		case opts.has("unix"):
+			return strconv.FormatInt(t.Unix(), 10), true, nil
+		case opts.has("unixmilli"):
+			return strconv.FormatInt(t.UnixMilli(), 10), true, nil
+		case layout != "":
+			return t.Format(layout), true, nil
+		default:
+			return t.Format(time.RFC3339), true, nil
+		}
+	}
+
+	if marshaler, ok := textMarshaler(fv); ok {
+		text, err := marshaler.MarshalText()
+		if err != nil {
+			return "", false, err
+		}
+		return string(text), true, nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		return fv.String(), true, nil
+	case reflect.Bool:
+		return strconv.FormatBool(fv.Bool()), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(fv.Int(), 10), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(fv.Uint(), 10), true, nil
+	case reflect.Float32:
+		return strconv.FormatFloat(fv.Float(), 'f', -1, 32), true, nil
+	case reflect.Float64:
+		return strconv.FormatFloat(fv.Float(), 'f', -1, 64), true, nil
+	default:
+		if stringer, ok := reflect.TypeAssert[fmt.Stringer](fv); ok {
+			return stringer.String(), true, nil
+		}
+		return "", false, fmt.Errorf("unsupported type: %s", fv.Type())
+	}
+}
+
+// textMarshaler returns the encoding.TextMarshaler implemented by fv or by its address.
+func textMarshaler(fv reflect.Value) (encoding.TextMarshaler, bool) {
+	if fv.Type().Implements(textMarshalerType) {
+		return reflect.TypeAssert[encoding.TextMarshaler](fv)
+	}
+
+	if fv.CanAddr() && reflect.PointerTo(fv.Type()).Implements(textMarshalerType) {
+		return reflect.TypeAssert[encoding.TextMarshaler](fv.Addr())
+	}
+
+	return nil, false
+}
+
+// implementsTextMarshaler reports whether fv, or its address, implements encoding.TextMarshaler.
+func implementsTextMarshaler(fv reflect.Value) bool {
+	_, ok := textMarshaler(fv)
+	return ok
+}
+
+// tagOptions are the comma-separated options following the name of a query tag.
+type tagOptions string
+
+// has reports whether the tag options contain the given option.
+func (o tagOptions) has(option string) bool {
+	for opt := range strings.SplitSeq(string(o), ",") {
+		if opt == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeQuery encodes query parameters sorted by key, keeping the order of the values
+// of each key. It is the canonical form used to merge query parameters into request URLs.
+func normalizeQuery(values url.Values) string {
+	return values.Encode()
+}
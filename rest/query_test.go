@@ -0,0 +1,158 @@
+package rest_test
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arielsrv/go-restclient/rest"
+)
+
+type Level int
+
+func (l Level) MarshalText() ([]byte, error) {
+	if l < 0 {
+		return nil, errors.New("invalid level")
+	}
+	return []byte(strings.Repeat("*", int(l))), nil
+}
+
+type Paging struct {
+	Page int `query:"page,omitempty"`
+	Size int `query:"size"`
+}
+
+type ListUsers struct {
+	Paging
+	Since   time.Time  `query:"since" layout:"2006-01-02"`
+	Until   time.Time  `query:"until,unix"`
+	Created *time.Time `query:"created"`
+	Active  *bool      `query:"active"`
+	Search  string     `query:"q,omitempty"`
+	Ignored string     `query:"-"`
+	Status  []string   `query:"status"`
+	Fields  []string   `query:"fields,comma"`
+	Levels  []Level    `query:"level,omitempty"`
+	Level   Level      `query:"min"`
+	Name    string
+	Ratio   float64 `query:"ratio,omitempty"`
+	secret  string
+}
+
+func TestEncodeQuery(t *testing.T) {
+	active := false
+	values, err := rest.EncodeQuery(&ListUsers{
+		Paging:  Paging{Size: 20},
+		Since:   time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC),
+		Until:   time.Unix(1700000000, 0),
+		Active:  &active,
+		Ignored: "ignored",
+		Status:  []string{"active", "blocked"},
+		Fields:  []string{"id", "name"},
+		Level:   2,
+		Name:    "John Doe",
+		Ratio:   0.5,
+		secret:  "secret",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, url.Values{
+		"size":   {"20"},
+		"since":  {"2025-03-01"},
+		"until":  {"1700000000"},
+		"active": {"false"},
+		"status": {"active", "blocked"},
+		"fields": {"id,name"},
+		"min":    {"**"},
+		"Name":   {"John Doe"},
+		"ratio":  {"0.5"},
+	}, values)
+}
+
+type sorting struct {
+	Sort  string `query:"sort"`
+	order string
+}
+
+type Cursor struct {
+	After string `query:"after"`
+}
+
+type ListOrders struct {
+	sorting
+	*Cursor
+	Status string `query:"status"`
+}
+
+func TestEncodeQuery_UnexportedEmbedded(t *testing.T) {
+	values, err := rest.EncodeQuery(ListOrders{
+		sorting: sorting{Sort: "-created", order: "desc"},
+		Cursor:  &Cursor{After: "abc"},
+		Status:  "paid",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, url.Values{
+		"sort":   {"-created"},
+		"after":  {"abc"},
+		"status": {"paid"},
+	}, values)
+}
+
+func TestEncodeQuery_Err(t *testing.T) {
+	_, err := rest.EncodeQuery("foo")
+	require.Error(t, err)
+
+	_, err = rest.EncodeQuery(ListUsers{Level: -1})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field Level")
+
+	_, err = rest.EncodeQuery(struct {
+		Channel chan int `query:"channel"`
+	}{Channel: make(chan int)})
+	require.Error(t, err)
+}
+
+func TestEncodeQuery_Nil(t *testing.T) {
+	values, err := rest.EncodeQuery((*ListUsers)(nil))
+	require.NoError(t, err)
+	assert.Empty(t, values)
+}
+
+func TestRequest_QueryStruct(t *testing.T) {
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Name=&min=&page=3&q=john&since=0001-01-01&size=10&sort=name&status=a&status=b&until=-62135596800",
+			r.URL.RawQuery)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	client := &rest.Client{BaseURL: srv.URL}
+
+	response := client.R().
+		QueryStruct(ListUsers{
+			Paging: Paging{Page: 3, Size: 10},
+			Search: "john",
+			Status: []string{"a"},
+		}).
+		Query("status", "b").
+		Get(t.Context(), "/users?sort=name")
+
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestWithQueryStruct_Err(t *testing.T) {
+	client := &rest.Client{BaseURL: server.URL}
+
+	ctx := rest.WithOptions(t.Context(), rest.WithQueryStruct(ListUsers{Level: -1}))
+	response := client.GetWithContext(ctx, "/user")
+
+	require.Error(t, response.Err)
+	assert.Contains(t, response.Err.Error(), "invalid level")
+}
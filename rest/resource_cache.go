@@ -30,6 +30,13 @@ type Cache[K Key, V any] interface {
 	SetWithTTL(key K, value V, cost int64, ttl time.Duration) bool
 }
 
+// deleter is implemented by the Cache implementations able to remove a value, such as
+// the default ristretto cache.
+type deleter[K Key] interface {
+	// Del removes the value of the specified key from the cache, if any.
+	Del(key K)
+}
+
 // resourceTTLLfuMap is an LRU-TTL Cache that caches Responses based on headers.
 // It implements a least-frequently-used eviction policy with time-to-live expiration.
 var resourceCache *resourceTTLLfuMap
@@ -113,3 +120,11 @@ func (r *resourceTTLLfuMap) setNX(url string, response *Response) {
 	}
 	r.lowLevelCache.Set(url, weak.Make(response), cost)
 }
+
+// del removes the value of the url from the cache, if any. Caches without Del keep it
+// until it expires.
+func (r *resourceTTLLfuMap) del(url string) {
+	if cache, ok := r.lowLevelCache.(deleter[string]); ok {
+		cache.Del(url)
+	}
+}
@@ -12,6 +12,8 @@ import (
 	"sync/atomic"
 	"time"
 	"unsafe"
+
+	"google.golang.org/protobuf/proto"
 )
 
 // Response represents an HTTP response from a REST API call.
@@ -37,6 +39,12 @@ type Response struct {
 	// lastModified is the Last-Modified timestamp from the response headers.
 	lastModified *time.Time
 
+	// Endpoint is the base URL of Client.BaseURLs the request was sent to, if any.
+	Endpoint string
+
+	// Hedged reports whether the response came from a hedged request (see Client.Hedging).
+	Hedged bool
+
 	// etag is the ETag value from the response headers.
 	etag string
 
@@ -47,6 +55,49 @@ type Response struct {
 	revalidate bool
 }
 
+// NewResponse wraps an *http.Response whose body has already been read into a Response,
+// parsing RFC7807 problem details when present. It is useful to stub HTTPClient
+// implementations, such as the generated mocks, with realistic responses.
+func NewResponse(response *http.Response, body []byte) *Response {
+	result := &Response{
+		Response: response,
+		bytes:    body,
+	}
+
+	if response != nil {
+		setProblem(result)
+	}
+
+	return result
+}
+
+// StatusError is the error reported for responses with an unexpected HTTP status code.
+// Problem holds the RFC7807 problem details when the server sent them.
+type StatusError struct {
+	// Problem contains the RFC7807 problem details of the response, if any.
+	Problem *Problem
+
+	// Body is the raw response body.
+	Body string
+
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+}
+
+// Error returns the status code and response body.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status code %d, body: %s", e.StatusCode, e.Body)
+}
+
+// newStatusError builds a StatusError from the given response.
+func newStatusError(response *Response) *StatusError {
+	return &StatusError{
+		StatusCode: response.StatusCode,
+		Body:       response.String(),
+		Problem:    response.Problem,
+	}
+}
+
 // size returns the size of the Response in bytes.
 // This is used for cache cost calculation.
 func (r *Response) size() int64 {
@@ -103,7 +154,8 @@ func (r *Response) Raw() string {
 
 // FillUp deserializes the response body into the provided value 'fill'.
 // 'fill' must be a pointer to the type where you want to store the data.
-// It automatically detects the content type (JSON, XML) from the response headers.
+// It automatically detects the content type (JSON, XML) from the response headers,
+// including structured syntax suffixes such as application/problem+json.
 func (r *Response) FillUp(fill any) error {
 	if r == nil {
 		return errors.New("response is nil")
@@ -125,24 +177,42 @@ func (r *Response) FillUp(fill any) error {
 		return fmt.Errorf("invalid content type: %s", contentType)
 	}
 
+	if mediaContent, found := findUnmarshaler(mediaType); found {
+		return mediaContent.Unmarshal(r.bytes, fill)
+	}
+
+	// Structured syntax suffix (RFC 6839), e.g. application/problem+json is read as application/json
+	if _, suffix, found := strings.Cut(mediaType, "+"); found {
+		if mediaContent, ok := findUnmarshaler("application/" + suffix); ok {
+			return mediaContent.Unmarshal(r.bytes, fill)
+		}
+	}
+
+	return fmt.Errorf("unmarshal fail, unsupported content type: %s", contentType)
+}
+
+// findUnmarshaler returns the registered MediaUnmarshaler whose Content-Type matches mediaType.
+func findUnmarshaler(mediaType string) (MediaUnmarshaler, bool) {
 	for mediaContent := range maps.Values(readMarshalers) {
 		values := mediaContent.DefaultHeaders().Values(CanonicalContentTypeHeader)
 		for i := range values {
 			value := values[i]
 			if mediaType == value {
-				return mediaContent.Unmarshal(r.bytes, fill)
+				return mediaContent, true
 			}
 		}
 	}
 
-	return fmt.Errorf("unmarshal fail, unsupported content type: %s", contentType)
+	return nil, false
 }
 
 // Deserialize is a generic helper that deserializes the response body into a new value of type T.
+// If T is a Protocol Buffers message pointer, a new message is allocated and filled in place.
 //
 // Example usage:
 //
 //	users, err := rest.Deserialize[[]User](response)
+//	user, err := rest.Deserialize[*pb.User](response)
 func Deserialize[T any](response *Response) (T, error) {
 	var dflt T
 	if response == nil {
@@ -150,6 +220,15 @@ func Deserialize[T any](response *Response) (T, error) {
 	}
 
 	var result T
+	if message, ok := any(result).(proto.Message); ok {
+		result, _ = message.ProtoReflect().Type().New().Interface().(T)
+		if err := response.FillUp(result); err != nil {
+			return dflt, err
+		}
+
+		return result, nil
+	}
+
 	err := response.FillUp(&result)
 	if err != nil {
 		return dflt, err
@@ -169,6 +248,7 @@ func (r *Response) Cached() bool {
 
 // Debug returns a string representation of both the HTTP request and response.
 // This is useful for logging and debugging purposes.
+// Credentials in the Authorization and Proxy-Authorization headers are redacted.
 func (r *Response) Debug() string {
 	if r == nil {
 		return "Response is nil"
@@ -178,7 +258,7 @@ func (r *Response) Debug() string {
 
 	if r.Request == nil {
 		strReq = "Request is nil"
-	} else if req, err := httputil.DumpRequest(r.Request, true); err != nil {
+	} else if req, err := dumpRequest(r.Request); err != nil {
 		strReq = err.Error()
 	} else {
 		strReq = string(req)
@@ -207,6 +287,23 @@ func (r *Response) Debug() string {
 	return dump
 }
 
+// dumpRequest dumps the request with its credentials redacted.
+func dumpRequest(request *http.Request) ([]byte, error) {
+	redacted := *request
+	redacted.Header = request.Header.Clone()
+	for _, key := range []string{AuthorizationHeader, ProxyAuthorizationHeader} {
+		if redacted.Header.Get(key) != "" {
+			redacted.Header.Set(key, "[REDACTED]")
+		}
+	}
+
+	dump, err := httputil.DumpRequest(&redacted, true)
+	// DumpRequest replaces a read body with a copy
+	request.Body = redacted.Body
+
+	return dump, err
+}
+
 // IsOk checks if the response status code is within the 200-399 range.
 // Returns true if the status code indicates success, false otherwise.
 func (r *Response) IsOk() bool {
@@ -219,7 +316,8 @@ func (r *Response) IsOk() bool {
 // VerifyIsOkOrError checks if the response is OK or if an error occurred.
 // Returns nil if the response is OK, otherwise returns an error with details.
 // If r.Err is not nil, it returns that error.
-// If the status code is not in the success range, it returns an error with the status code and response body.
+// If the status code is not in the success range, it returns a *StatusError with the status code,
+// response body and problem details, wrapped in a *ConflictError for 412 (Precondition Failed).
 func (r *Response) VerifyIsOkOrError() error {
 	if r == nil {
 		return errors.New("response is nil")
@@ -232,7 +330,7 @@ func (r *Response) VerifyIsOkOrError() error {
 	}
 
 	if !r.IsOk() {
-		return fmt.Errorf("status code %d, body: %s", r.StatusCode, r.String())
+		return statusError(r)
 	}
 
 	return nil
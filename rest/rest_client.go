@@ -6,6 +6,8 @@ import (
 	"net/url"
 	"sync"
 	"time"
+
+	"golang.org/x/oauth2"
 )
 
 // The default dfltTransport used by all RequestBuilders
@@ -105,15 +107,38 @@ type Client struct {
 	// If nil, the default transport is used.
 	CustomPool *CustomPool
 
-	// BasicAuth sets the username and password for Basic Authentication.
+	// TLS configures client certificates, root CAs, the minimum TLS version and SNI.
+	TLS *TLSConfig
+
+	// Proxy selects the HTTP or SOCKS5 proxy of each request, with NO_PROXY semantics.
+	// If nil, the proxy is taken from the environment, or from CustomPool.Proxy.
+	Proxy *ProxyConfig
+
+	// Dialer replaces the dialer or tunes the default one: keep-alive, DNS resolver,
+	// IP version and dual-stack fallback delay.
+	Dialer *Dialer
+
+	// BasicAuth sets the username and password for Basic Authentication. It is applied
+	// before Auth, and ignored when OAuth is set.
 	BasicAuth *BasicAuth
 
+	// DigestAuth sets the username and password for Digest Authentication.
+	DigestAuth *DigestAuth
+
 	// Client is the underlying http.Client. If not provided, one will be created.
 	Client *http.Client
 
 	// OAuth credentials for OAuth2 authentication.
 	OAuth *OAuth
 
+	// Auth adds credentials to every request, after OAuth when both are set.
+	// See BearerToken, BearerTokenFunc, APIKeyHeader, APIKeyQuery and ChainAuth.
+	Auth Auth
+
+	// Signer signs every request after its headers, credentials and body are final.
+	// See HMACSigner and SigV4Signer.
+	Signer Signer
+
 	// DefaultHeaders are headers included in every request.
 	DefaultHeaders http.Header
 
@@ -121,20 +146,60 @@ type Client struct {
 	defaultHeaders sync.Map
 
 	// BaseURL is the prefix for all request URLs. Final URL = BaseURL + path.
+	// A "unix:///path/to/socket" BaseURL sends every request over the Unix domain socket,
+	// its responses being cached by the BaseURL followed by the request path.
 	BaseURL string
 
+	// BaseURLs are the base URLs of the replicas of a service, replacing BaseURL. Requests
+	// are spread across them as configured by LoadBalancer, round-robin by default, and
+	// cached by their URL on the first one. Unix domain sockets are not supported, and
+	// fail every request of the client.
+	BaseURLs []string
+
+	// LoadBalancer selects the endpoint of BaseURLs of each request and ejects failing ones.
+	LoadBalancer *LoadBalancer
+
+	// balancer tracks the load and health of BaseURLs (internal use).
+	balancer     *balancer
+	balancerOnce sync.Once
+
+	// Hedging sends a second copy of GET, HEAD and OPTIONS requests that are slow to
+	// answer, to another endpoint of BaseURLs when there are several.
+	Hedging *Hedging
+
+	// hedger tracks the latencies and the hedging budget (internal use).
+	hedger     *hedger
+	hedgerOnce sync.Once
+
+	// Bulkhead caps the requests in flight, queueing the requests beyond the limit.
+	Bulkhead *Bulkhead
+
+	// Retry retries idempotent requests failing with a transient error, within a budget.
+	Retry *Retry
+
+	// Idempotency adds an Idempotency-Key header to POST and PATCH requests, or requires
+	// one from the caller on some routes.
+	Idempotency *Idempotency
+
 	// UserAgent is the User-Agent header value for all requests.
 	UserAgent string
 
 	// Name is a label for the client, used in metrics.
 	Name string
 
-	// Timeout is the maximum time for the entire request/response cycle.
+	// Timeout is the maximum time to wait for the response headers.
+	// Override it per call with WithHeaderTimeout, or bound the whole request with WithTimeout.
 	Timeout time.Duration
 
 	// ConnectTimeout is the maximum time allowed to establish a connection.
+	// Override it per call with WithConnectTimeout.
 	ConnectTimeout time.Duration
 
+	// StreamIdleTimeout is the maximum time to wait for data on a Server-Sent Events
+	// stream, including its response headers, before the connection is dropped and
+	// re-established. It replaces Timeout for Events. Zero disables it.
+	StreamIdleTimeout time.Duration
+
 	// ContentType specifies the default media type (JSON, XML, Form).
 	ContentType ContentType
 
@@ -176,20 +241,112 @@ const (
 	AuthStyleInHeader
 )
 
+// GrantType selects the OAuth2 grant used to request tokens.
+type GrantType string
+
+const (
+	// GrantClientCredentials is the client credentials grant (RFC 6749 section 4.4).
+	// It is the default when no grant is set.
+	GrantClientCredentials GrantType = "client_credentials"
+
+	// GrantRefreshToken redeems a stored refresh token (RFC 6749 section 6).
+	// Rotated refresh tokens are kept for the next refresh.
+	GrantRefreshToken GrantType = "refresh_token"
+
+	// GrantJWTBearer exchanges a signed JWT assertion for a token (RFC 7523),
+	// e.g. for service accounts.
+	GrantJWTBearer GrantType = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+
+	// GrantTokenExchange exchanges a subject token for a token (RFC 8693),
+	// e.g. for on-behalf-of calls.
+	GrantTokenExchange GrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+)
+
+// Token type identifiers of the token exchange grant (RFC 8693 section 3).
+const (
+	TokenTypeAccessToken  = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeRefreshToken = "urn:ietf:params:oauth:token-type:refresh_token"
+	TokenTypeIDToken      = "urn:ietf:params:oauth:token-type:id_token"
+	TokenTypeJWT          = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// OAuth configures OAuth2 authentication. Tokens are requested with the selected Grant,
+// the client credentials grant by default, unless TokenSource is set, e.g. to the one
+// returned by PKCE.
+//
+// Example usage:
+//
+//	client := &rest.Client{
+//	    BaseURL: "https://api.example.com",
+//	    OAuth: &rest.OAuth{
+//	        Grant:     rest.GrantJWTBearer,
+//	        TokenURL:  "https://idp.example.com/token",
+//	        AuthStyle: rest.AuthStyleInParams,
+//	        Assertion: func(ctx context.Context) (string, error) {
+//	            return signServiceAccountJWT(ctx)
+//	        },
+//	    },
+//	}
 type OAuth struct {
+	// TokenSource, when set, provides the tokens instead of the grant.
+	// It is cached and refreshed like any other token source of the client.
+	TokenSource oauth2.TokenSource
+
+	// EndpointParams are additional parameters of every token request.
 	EndpointParams url.Values
-	ClientID       string
-	ClientSecret   string
-	TokenURL       string
-	Scopes         []string
-	AuthStyle      AuthStyle
+
+	// Assertion returns the signed JWT of the JWT bearer grant. It is called for every
+	// token request, so it can issue short-lived assertions.
+	Assertion func(ctx context.Context) (string, error)
+
+	// TokenExchange holds the parameters of the token exchange grant.
+	TokenExchange *TokenExchange
+
+	ClientID     string
+	ClientSecret string
+	TokenURL     string
+
+	// RefreshToken is the stored refresh token redeemed by the refresh token grant.
+	RefreshToken string
+
+	// Grant is the grant used to request tokens. Empty means GrantClientCredentials.
+	Grant GrantType
+
+	Scopes    []string
+	AuthStyle AuthStyle
+}
+
+// TokenExchange holds the parameters of the token exchange grant (RFC 8693).
+// The token is shared by every request of the client, so use one Client per subject.
+type TokenExchange struct {
+	// SubjectToken returns the token representing the party on whose behalf the request
+	// is made. It is called for every token request.
+	SubjectToken func(ctx context.Context) (string, error)
+
+	// ActorToken optionally returns the token of the acting party, for delegation.
+	ActorToken func(ctx context.Context) (string, error)
+
+	// SubjectTokenType is the type of the subject token, TokenTypeAccessToken by default.
+	SubjectTokenType string
+
+	// ActorTokenType is the type of the actor token, TokenTypeAccessToken by default.
+	ActorTokenType string
+
+	// RequestedTokenType is the type of the requested token, if any.
+	RequestedTokenType string
+
+	// Audience and Resource identify the target services of the requested token.
+	Audience []string
+	Resource []string
 }
 
 // CustomPool defines a separate internal *dfltTransport* and connection pooling.
 type CustomPool struct {
 	// Public for custom fine-tuning
 	Transport http.RoundTripper
-	Proxy     string
+	// Proxy is the URL of the proxy of every request, with scheme http, https, socks5 or
+	// socks5h. An invalid URL fails every request of the client.
+	Proxy string
 
 	MaxIdleConnsPerHost int
 }
@@ -9,6 +9,9 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 )
 
 // MIME type constants for various content types used in HTTP requests and responses.
@@ -31,8 +34,29 @@ const (
 	// MIMEApplicationProblemXML is the MIME type for RFC7807 problem details in XML format.
 	MIMEApplicationProblemXML = "application/problem+xml"
 
+	// MIMEApplicationJSONPatch is the MIME type for JSON Patch (RFC 6902) documents.
+	MIMEApplicationJSONPatch = "application/json-patch+json"
+
+	// MIMEApplicationMergePatch is the MIME type for JSON Merge Patch (RFC 7396) documents.
+	MIMEApplicationMergePatch = "application/merge-patch+json"
+
 	// MIMEApplicationForm is the MIME type for form-urlencoded content.
 	MIMEApplicationForm = "application/x-www-form-urlencoded"
+
+	// MIMEApplicationNDJSON is the MIME type for newline-delimited JSON streams.
+	MIMEApplicationNDJSON = "application/x-ndjson"
+
+	// MIMEApplicationJSONLines is the MIME type for JSON Lines streams.
+	MIMEApplicationJSONLines = "application/jsonl"
+
+	// MIMETextEventStream is the MIME type for Server-Sent Events streams.
+	MIMETextEventStream = "text/event-stream"
+
+	// MIMEApplicationProtobuf is the MIME type for Protocol Buffers binary content.
+	MIMEApplicationProtobuf = "application/protobuf"
+
+	// MIMEApplicationXProtobuf is the legacy MIME type for Protocol Buffers binary content.
+	MIMEApplicationXProtobuf = "application/x-protobuf"
 )
 
 // HTTP header constants for content negotiation.
@@ -59,6 +83,9 @@ const (
 
 	// FORM represents a form-urlencoded content type.
 	FORM
+
+	// PROTOBUF represents a Protocol Buffers binary content type.
+	PROTOBUF
 )
 
 // Media instances for each supported content type.
@@ -72,21 +99,26 @@ var (
 	formMedia = &FormMedia{
 		ContentType: MIMEApplicationForm,
 	}
+	protobufMedia = &ProtobufMedia{
+		ContentType: MIMEApplicationXProtobuf,
+	}
 )
 
 // Maps of content types to their respective marshalers and unmarshalers.
 var (
 	// contentMarshalers maps ContentType to MediaMarshaler for request body serialization.
 	contentMarshalers = map[ContentType]MediaMarshaler{
-		JSON: jsonMedia,
-		XML:  xmlMedia,
-		FORM: formMedia,
+		JSON:     jsonMedia,
+		XML:      xmlMedia,
+		FORM:     formMedia,
+		PROTOBUF: protobufMedia,
 	}
 
 	// readMarshalers maps ContentType to MediaUnmarshaler for response body deserialization.
 	readMarshalers = map[ContentType]MediaUnmarshaler{
-		JSON: jsonMedia,
-		XML:  xmlMedia,
+		JSON:     jsonMedia,
+		XML:      xmlMedia,
+		PROTOBUF: protobufMedia,
 	}
 )
 
@@ -123,7 +155,17 @@ type JSONMedia struct {
 
 // Marshal converts the given body into JSON format and returns an io.Reader
 // containing the marshaled data. It supports strings, byte slices, structs, and maps.
+// A proto.Message body is encoded with protojson.
 func (r JSONMedia) Marshal(body any) (io.Reader, error) {
+	if message, ok := body.(proto.Message); ok {
+		b, err := protojson.Marshal(message)
+		if err != nil {
+			return nil, err
+		}
+
+		return bytes.NewBuffer(b), nil
+	}
+
 	b, err := json.Marshal(body)
 	if err != nil {
 		return nil, err
@@ -133,8 +175,12 @@ func (r JSONMedia) Marshal(body any) (io.Reader, error) {
 }
 
 // Unmarshal parses the JSON-encoded data and stores the result in the value
-// pointed to by v.
+// pointed to by v. A proto.Message target is decoded with protojson.
 func (r JSONMedia) Unmarshal(data []byte, v any) error {
+	if message, ok := v.(proto.Message); ok {
+		return protojson.Unmarshal(data, message)
+	}
+
 	return json.Unmarshal(data, v)
 }
 
@@ -225,3 +271,60 @@ func (r FormMedia) DefaultHeaders() http.Header {
 		},
 	}
 }
+
+// ProtobufMedia implements the Media, MediaMarshaler, and MediaUnmarshaler interfaces
+// for Protocol Buffers binary content type.
+type ProtobufMedia struct {
+	// ContentType is the MIME type for this media, typically "application/x-protobuf".
+	ContentType string
+}
+
+// Marshal converts the given body into Protocol Buffers wire format and returns
+// an io.Reader containing the marshaled data. The body must implement proto.Message.
+func (r ProtobufMedia) Marshal(body any) (io.Reader, error) {
+	message, ok := body.(proto.Message)
+	if !ok {
+		return nil, errors.New("body must be of type proto.Message")
+	}
+
+	b, err := proto.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.NewBuffer(b), nil
+}
+
+// Unmarshal parses the Protocol Buffers wire-format data and stores the result
+// in the message pointed to by v. v must implement proto.Message.
+func (r ProtobufMedia) Unmarshal(data []byte, v any) error {
+	message, ok := v.(proto.Message)
+	if !ok {
+		return errors.New("target must be of type proto.Message")
+	}
+
+	return proto.Unmarshal(data, message)
+}
+
+// DefaultHeaders returns the default HTTP headers for Protocol Buffers content type.
+// It sets the Content-Type header to the configured ContentType, followed by the
+// alternative protobuf MIME type so that responses using either are recognized,
+// and the Accept header to accept both protobuf variants and JSON problem responses.
+func (r ProtobufMedia) DefaultHeaders() http.Header {
+	alias := MIMEApplicationProtobuf
+	if r.ContentType == MIMEApplicationProtobuf {
+		alias = MIMEApplicationXProtobuf
+	}
+
+	return http.Header{
+		CanonicalContentTypeHeader: []string{
+			r.ContentType,
+			alias,
+		},
+		CanonicalAcceptHeader: []string{
+			MIMEApplicationXProtobuf,
+			MIMEApplicationProtobuf,
+			MIMEApplicationProblemJSON,
+		},
+	}
+}
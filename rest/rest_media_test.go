@@ -0,0 +1,112 @@
+package rest_test
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/arielsrv/go-restclient/rest"
+)
+
+func TestPost_Protobuf(t *testing.T) {
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, rest.MIMEApplicationXProtobuf, r.Header.Get("Content-Type"))
+		assert.Equal(t, rest.MIMEApplicationXProtobuf, r.Header.Get("Accept"))
+
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		var in wrapperspb.StringValue
+		assert.NoError(t, proto.Unmarshal(body, &in))
+
+		out, err := proto.Marshal(wrapperspb.String("hello " + in.GetValue()))
+		assert.NoError(t, err)
+
+		w.Header().Set("Content-Type", rest.MIMEApplicationProtobuf)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(out)
+	}))
+
+	client := &rest.Client{
+		BaseURL:     srv.URL,
+		ContentType: rest.PROTOBUF,
+	}
+
+	resp := client.Post("/greet", wrapperspb.String("Maria"))
+	require.NoError(t, resp.Err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var filled wrapperspb.StringValue
+	require.NoError(t, resp.FillUp(&filled))
+	assert.Equal(t, "hello Maria", filled.GetValue())
+
+	typed, err := rest.Deserialize[*wrapperspb.StringValue](resp)
+	require.NoError(t, err)
+	assert.Equal(t, "hello Maria", typed.GetValue())
+}
+
+func TestPost_Protobuf_Err(t *testing.T) {
+	client := &rest.Client{
+		BaseURL:     server.URL,
+		ContentType: rest.PROTOBUF,
+	}
+
+	resp := client.Post("/user", &User{Name: "Maria"})
+	require.Error(t, resp.Err)
+	assert.Contains(t, resp.Err.Error(), "proto.Message")
+}
+
+func TestGet_ProtoJSON(t *testing.T) {
+	expected, err := structpb.NewStruct(map[string]any{"name": "Maria", "id": 1})
+	require.NoError(t, err)
+
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			body, rErr := io.ReadAll(r.Body)
+			assert.NoError(t, rErr)
+
+			var in structpb.Struct
+			assert.NoError(t, protojson.Unmarshal(body, &in))
+			assert.Equal(t, "Maria", in.GetFields()["name"].GetStringValue())
+		}
+
+		out, mErr := protojson.Marshal(expected)
+		assert.NoError(t, mErr)
+
+		w.Header().Set("Content-Type", rest.MIMEApplicationJSON)
+		_, _ = w.Write(out)
+	}))
+
+	client := &rest.Client{
+		BaseURL:     srv.URL,
+		ContentType: rest.JSON,
+	}
+
+	resp := client.Post("/user", expected)
+	require.NoError(t, resp.Err)
+
+	resp = client.Get("/user")
+	require.NoError(t, resp.Err)
+
+	typed, err := rest.Deserialize[*structpb.Struct](resp)
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(expected, typed))
+}
+
+func TestProtobufMedia_Unmarshal_Err(t *testing.T) {
+	media := rest.ProtobufMedia{ContentType: rest.MIMEApplicationProtobuf}
+
+	err := media.Unmarshal([]byte{}, &User{})
+	require.Error(t, err)
+
+	headers := media.DefaultHeaders()
+	assert.Equal(t, []string{rest.MIMEApplicationProtobuf, rest.MIMEApplicationXProtobuf},
+		headers.Values("Content-Type"))
+}
@@ -0,0 +1,315 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RequestOption configures a single request.
+//
+// Options travel in the request context (see WithOptions), so they apply to every
+// HTTPClient method without changing its signature, and are also set by the
+// fluent Request builder returned by Client.R.
+type RequestOption func(*requestOptions)
+
+// requestOptions holds the per-call settings collected from RequestOption values.
+// Once stored in a context it must be treated as immutable.
+type requestOptions struct {
+	err            error
+	pathParams     map[string]string
+	query          url.Values
+	route          string
+	timeout        time.Duration
+	headerTimeout  time.Duration
+	connectTimeout time.Duration
+	idempotencyKey string
+	ifMatch        func(cacheURL string) string
+	eventStream    bool
+}
+
+// ErrInvalidPathParam is reported in Response.Err when a path param is a dot segment
+// ("." or ".."), which would change the path of the request instead of filling it.
+var ErrInvalidPathParam = errors.New("invalid path param")
+
+// requestOptionsKey is the context key for requestOptions.
+type requestOptionsKey struct{}
+
+// activeOptionsKey is the context key for the requestOptions of the request in flight.
+type activeOptionsKey struct{}
+
+// routeKey is the context key for the route template of the current request.
+type routeKey struct{}
+
+// WithOptions returns a copy of ctx carrying the given request options, on top of
+// any options already present in ctx.
+//
+// The options apply to every request made with the returned context, or a context
+// derived from it, not only to the next one: derive a new context per request when the
+// options, such as an idempotency key or path params, identify a single call. They are
+// hidden from the context of the request they apply to, so requests made from within it,
+// e.g. by a transport or an Auth provider, do not inherit them.
+//
+// Example usage:
+//
+//	ctx = rest.WithOptions(ctx, rest.WithPathParam("id", id), rest.WithQuery("page", 2))
+//	response := client.GetWithContext(ctx, "/users/{id}")
+func WithOptions(ctx context.Context, opts ...RequestOption) context.Context {
+	options := optionsFromContext(ctx).clone()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return context.WithValue(ctx, requestOptionsKey{}, options)
+}
+
+// WithPathParam replaces the {key} placeholder of the request URL with the
+// path-escaped value. Dot segments ("." and "..") are rejected with ErrInvalidPathParam,
+// so a value cannot escape the route.
+func WithPathParam(key, value string) RequestOption {
+	return func(options *requestOptions) {
+		if value == "." || value == ".." {
+			options.err = errors.Join(options.err, fmt.Errorf("%w: %s=%q is a dot segment", ErrInvalidPathParam, key, value))
+			return
+		}
+
+		if options.pathParams == nil {
+			options.pathParams = make(map[string]string)
+		}
+		options.pathParams[key] = value
+	}
+}
+
+// WithQuery adds a query parameter to the request URL. Values are formatted with
+// fmt.Sprint unless they are strings or implement fmt.Stringer.
+func WithQuery(key string, values ...any) RequestOption {
+	return func(options *requestOptions) {
+		if options.query == nil {
+			options.query = make(url.Values)
+		}
+		for _, value := range values {
+			options.query.Add(key, formatQueryValue(value))
+		}
+	}
+}
+
+// WithRoute sets the route template reported to tracing, e.g. "/users/{id}".
+// It is set automatically by the Request builder and when path params are used.
+func WithRoute(route string) RequestOption {
+	return func(options *requestOptions) {
+		options.route = route
+	}
+}
+
+// WithTimeout bounds the whole request, including reading the response body.
+// A sooner deadline of the request context takes precedence.
+func WithTimeout(timeout time.Duration) RequestOption {
+	return func(options *requestOptions) {
+		options.timeout = timeout
+	}
+}
+
+// WithHeaderTimeout bounds the wait for the response headers, overriding the client's
+// Timeout for this request only. It may be longer than the client's Timeout, so a single
+// slow endpoint does not force a large timeout for the whole client.
+// A sooner deadline of the request context takes precedence.
+func WithHeaderTimeout(timeout time.Duration) RequestOption {
+	return func(options *requestOptions) {
+		options.headerTimeout = timeout
+	}
+}
+
+// WithConnectTimeout bounds the establishment of a new connection, overriding the
+// client's ConnectTimeout for this request only. Reused connections are not affected.
+// A sooner deadline of the request context takes precedence.
+func WithConnectTimeout(timeout time.Duration) RequestOption {
+	return func(options *requestOptions) {
+		options.connectTimeout = timeout
+	}
+}
+
+// WithIdempotencyKey sets the Idempotency-Key header of the request, identifying its
+// logical operation. Any method may carry a key; it is sent unchanged on every retry.
+func WithIdempotencyKey(key string) RequestOption {
+	return func(options *requestOptions) {
+		options.idempotencyKey = key
+	}
+}
+
+// WithIfMatch makes a write conditional on the resource being unchanged since the given
+// response was read: its ETag is sent in the If-Match header, and a 412 (Precondition
+// Failed) response yields a *ConflictError. The request fails with ErrMissingETag when
+// the response has no ETag. Only PUT, PATCH and DELETE requests are conditional, other
+// methods ignore it.
+func WithIfMatch(response *Response) RequestOption {
+	return func(options *requestOptions) {
+		options.ifMatch = func(string) string {
+			return response.ETag()
+		}
+	}
+}
+
+// WithIfMatchCached is like WithIfMatch with the cached response of the same URL, e.g. of
+// a previous GET with Client.EnableCache. The request fails with ErrMissingETag when the
+// URL has no cached response with an ETag.
+func WithIfMatchCached() RequestOption {
+	return func(options *requestOptions) {
+		options.ifMatch = cachedETag
+	}
+}
+
+// RouteFromContext returns the route template of the request carrying ctx,
+// or an empty string if none was set. Custom transports can use it to label
+// metrics without the cardinality of the expanded URL.
+func RouteFromContext(ctx context.Context) string {
+	if route, ok := ctx.Value(routeKey{}).(string); ok {
+		return route
+	}
+
+	return ""
+}
+
+// optionsFromContext returns the request options carried by ctx, or empty options.
+func optionsFromContext(ctx context.Context) *requestOptions {
+	if options, ok := ctx.Value(requestOptionsKey{}).(*requestOptions); ok && options != nil {
+		return options
+	}
+
+	return &requestOptions{}
+}
+
+// activeOptions returns the options of the request in flight carrying ctx, as set by
+// applyOptions, or empty options.
+func activeOptions(ctx context.Context) *requestOptions {
+	if options, ok := ctx.Value(activeOptionsKey{}).(*requestOptions); ok {
+		return options
+	}
+
+	return &requestOptions{}
+}
+
+// clone returns a deep copy of the options.
+func (r *requestOptions) clone() *requestOptions {
+	options := *r
+	options.pathParams = maps.Clone(r.pathParams)
+	if r.query != nil {
+		options.query = make(url.Values, len(r.query))
+		for key, values := range r.query {
+			options.query[key] = slices.Clone(values)
+		}
+	}
+
+	return &options
+}
+
+// routeFor returns the route template for the given URL, without its query string:
+// the explicit route if set, otherwise the URL itself when it has path params to expand.
+func (r *requestOptions) routeFor(apiURL string) string {
+	route := r.route
+	if route == "" && len(r.pathParams) > 0 {
+		route = apiURL
+	}
+
+	route, _, _ = strings.Cut(route, "?")
+
+	return route
+}
+
+// expand replaces the {key} placeholders of apiURL with the path-escaped path params,
+// in a single pass: placeholders within the values are not expanded.
+func (r *requestOptions) expand(apiURL string) string {
+	if len(r.pathParams) == 0 {
+		return apiURL
+	}
+
+	replacements := make([]string, 0, 2*len(r.pathParams))
+	for _, key := range slices.Sorted(maps.Keys(r.pathParams)) {
+		replacements = append(replacements, "{"+key+"}", url.PathEscape(r.pathParams[key]))
+	}
+
+	return strings.NewReplacer(replacements...).Replace(apiURL)
+}
+
+// formatQueryValue formats a single query parameter value.
+func formatQueryValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// joinURL resolves apiURL against baseURL following RFC 3986. The base path is
+// treated as a directory, so "https://host/api" and "/users" give "https://host/api/users"
+// with no duplicated slashes. An absolute apiURL is used as is.
+func joinURL(baseURL, apiURL string) (*url.URL, error) {
+	ref, err := url.Parse(apiURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if baseURL == "" || ref.IsAbs() {
+		return ref, nil
+	}
+
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if ref.Path == "" {
+		return base.ResolveReference(ref), nil
+	}
+
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+		if base.RawPath != "" {
+			base.RawPath += "/"
+		}
+	}
+
+	ref.Path = strings.TrimLeft(ref.Path, "/")
+	ref.RawPath = strings.TrimLeft(ref.RawPath, "/")
+
+	return base.ResolveReference(ref), nil
+}
+
+// mergeQuery adds the given query parameters to u. The resulting query string is
+// normalized with normalizeQuery.
+func mergeQuery(u *url.URL, query url.Values) {
+	if len(query) == 0 {
+		return
+	}
+
+	values := u.Query()
+	for key, value := range query {
+		values[key] = append(values[key], value...)
+	}
+
+	u.RawQuery = normalizeQuery(values)
+}
+
+// applyOptions applies the request options to ctx: it records them and the route
+// template for the request in flight, hiding them from requests made with its context,
+// and bounds the request with the configured timeout. The returned cancel function
+// must be called once the response body has been read.
+func applyOptions(ctx context.Context, options *requestOptions, apiURL string) (context.Context, context.CancelFunc) {
+	ctx = context.WithValue(ctx, activeOptionsKey{}, options)
+	ctx = context.WithValue(ctx, requestOptionsKey{}, (*requestOptions)(nil))
+	// An empty route also replaces the route of an enclosing request
+	ctx = context.WithValue(ctx, routeKey{}, options.routeFor(apiURL))
+
+	if options.timeout > 0 {
+		return context.WithTimeout(ctx, options.timeout)
+	}
+
+	return ctx, func() {}
+}
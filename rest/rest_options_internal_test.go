@@ -0,0 +1,78 @@
+package rest
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func Test_joinURL(t *testing.T) {
+	tests := []struct {
+		base     string
+		apiURL   string
+		expected string
+	}{
+		{base: "", apiURL: "http://example.com/users", expected: "http://example.com/users"},
+		{base: "http://example.com", apiURL: "/users", expected: "http://example.com/users"},
+		{base: "http://example.com/", apiURL: "/users", expected: "http://example.com/users"},
+		{base: "http://example.com/api", apiURL: "users", expected: "http://example.com/api/users"},
+		{base: "http://example.com/api/", apiURL: "/users/", expected: "http://example.com/api/users/"},
+		{base: "http://example.com/api", apiURL: "/users/../orders", expected: "http://example.com/api/orders"},
+		{base: "http://example.com/api", apiURL: "?page=2", expected: "http://example.com/api?page=2"},
+		{base: "http://example.com/api", apiURL: "", expected: "http://example.com/api"},
+		{base: "http://example.com/api", apiURL: "https://other.com/x", expected: "https://other.com/x"},
+		{base: "http://example.com", apiURL: "/a%2Fb", expected: "http://example.com/a%2Fb"},
+	}
+
+	for _, tt := range tests {
+		got, err := joinURL(tt.base, tt.apiURL)
+		if err != nil {
+			t.Fatalf("joinURL(%q, %q): unexpected err: %v", tt.base, tt.apiURL, err)
+		}
+		if got.String() != tt.expected {
+			t.Errorf("joinURL(%q, %q) = %q, want %q", tt.base, tt.apiURL, got.String(), tt.expected)
+		}
+	}
+}
+
+func Test_requestOptions_clone(t *testing.T) {
+	parent := &requestOptions{}
+	WithQuery("a", 1)(parent)
+
+	child := parent.clone()
+	WithQuery("a", 2)(child)
+
+	if got := parent.query["a"]; len(got) != 1 {
+		t.Fatalf("parent options should not change, got %v", got)
+	}
+	if got := child.query["a"]; len(got) != 2 {
+		t.Fatalf("child options should have both values, got %v", got)
+	}
+
+	u, _ := url.Parse("http://example.com/?b=1")
+	mergeQuery(u, child.query)
+	if u.RawQuery != "a=1&a=2&b=1" {
+		t.Fatalf("unexpected query: %s", u.RawQuery)
+	}
+}
+
+func Test_requestOptions_expand(t *testing.T) {
+	options := &requestOptions{}
+	WithPathParam("a", "{b}")(options)
+	WithPathParam("b", "x/y")(options)
+
+	// Values are not expanded again, whatever the order of the params
+	for range 20 {
+		if got := options.expand("/a/{a}/b/{b}"); got != "/a/%7Bb%7D/b/x%2Fy" {
+			t.Fatalf("unexpected expansion: %s", got)
+		}
+	}
+
+	for _, value := range []string{".", ".."} {
+		options := &requestOptions{}
+		WithPathParam("id", value)(options)
+		if !errors.Is(options.err, ErrInvalidPathParam) {
+			t.Fatalf("path param %q should be rejected, got %v", value, options.err)
+		}
+	}
+}
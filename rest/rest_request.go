@@ -0,0 +1,151 @@
+package rest
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// Request is a fluent builder for a single request issued by a Client.
+// Path params are escaped, query params are encoded and the URL is resolved against
+// the client's BaseURL following RFC 3986. The route template, e.g. "/users/{id}",
+// is kept for tracing and can be read by custom transports with RouteFromContext.
+//
+// A Request is not safe for concurrent use; create one per call with Client.R.
+//
+// Example usage:
+//
+//	response := client.R().
+//	    PathParam("id", id).
+//	    Query("page", 2).
+//	    Header("X-Tenant", tenant).
+//	    Timeout(2 * time.Second).
+//	    Get(ctx, "/users/{id}")
+type Request struct {
+	client  *Client
+	headers http.Header
+	options []RequestOption
+}
+
+// R returns a new Request builder for the client.
+func (r *Client) R() *Request {
+	return &Request{
+		client:  r,
+		headers: make(http.Header),
+	}
+}
+
+// PathParam replaces the {key} placeholder of the route with the path-escaped value.
+func (r *Request) PathParam(key, value string) *Request {
+	return r.With(WithPathParam(key, value))
+}
+
+// PathParams replaces the {key} placeholders of the route with the path-escaped values.
+func (r *Request) PathParams(params map[string]string) *Request {
+	for _, key := range slices.Sorted(maps.Keys(params)) {
+		r.PathParam(key, params[key])
+	}
+
+	return r
+}
+
+// Query adds a query parameter. Values are formatted with fmt.Sprint unless they
+// are strings or implement fmt.Stringer.
+func (r *Request) Query(key string, values ...any) *Request {
+	return r.With(WithQuery(key, values...))
+}
+
+// QueryStruct adds the query parameters encoded from the `query` tags of v.
+// See EncodeQuery for the supported field types and tag options.
+func (r *Request) QueryStruct(v any) *Request {
+	return r.With(WithQueryStruct(v))
+}
+
+// Header adds a header value for this request only.
+func (r *Request) Header(key, value string) *Request {
+	r.headers.Add(key, value)
+	return r
+}
+
+// Timeout bounds the whole request, including reading the response body.
+func (r *Request) Timeout(timeout time.Duration) *Request {
+	return r.With(WithTimeout(timeout))
+}
+
+// HeaderTimeout bounds the wait for the response headers, overriding the client's Timeout.
+func (r *Request) HeaderTimeout(timeout time.Duration) *Request {
+	return r.With(WithHeaderTimeout(timeout))
+}
+
+// ConnectTimeout bounds the establishment of a new connection, overriding the client's ConnectTimeout.
+func (r *Request) ConnectTimeout(timeout time.Duration) *Request {
+	return r.With(WithConnectTimeout(timeout))
+}
+
+// IdempotencyKey sets the Idempotency-Key header identifying the operation of this request.
+func (r *Request) IdempotencyKey(key string) *Request {
+	return r.With(WithIdempotencyKey(key))
+}
+
+// IfMatch makes this request conditional on the ETag of the given response.
+func (r *Request) IfMatch(response *Response) *Request {
+	return r.With(WithIfMatch(response))
+}
+
+// IfMatchCached makes this request conditional on the ETag of the cached response of its URL.
+func (r *Request) IfMatchCached() *Request {
+	return r.With(WithIfMatchCached())
+}
+
+// With applies additional request options.
+func (r *Request) With(opts ...RequestOption) *Request {
+	r.options = append(r.options, opts...)
+	return r
+}
+
+// Get issues a GET HTTP verb to the given route.
+func (r *Request) Get(ctx context.Context, route string) *Response {
+	return r.send(ctx, http.MethodGet, route, nil)
+}
+
+// Post issues a POST HTTP verb to the given route.
+// Body could be any of the form: string, []byte, struct & map.
+func (r *Request) Post(ctx context.Context, route string, body any) *Response {
+	return r.send(ctx, http.MethodPost, route, body)
+}
+
+// Put issues a PUT HTTP verb to the given route.
+// Body could be any of the form: string, []byte, struct & map.
+func (r *Request) Put(ctx context.Context, route string, body any) *Response {
+	return r.send(ctx, http.MethodPut, route, body)
+}
+
+// Patch issues a PATCH HTTP verb to the given route.
+// Body could be any of the form: string, []byte, struct & map.
+func (r *Request) Patch(ctx context.Context, route string, body any) *Response {
+	return r.send(ctx, http.MethodPatch, route, body)
+}
+
+// Delete issues a DELETE HTTP verb to the given route.
+func (r *Request) Delete(ctx context.Context, route string) *Response {
+	return r.send(ctx, http.MethodDelete, route, nil)
+}
+
+// Head issues a HEAD HTTP verb to the given route.
+func (r *Request) Head(ctx context.Context, route string) *Response {
+	return r.send(ctx, http.MethodHead, route, nil)
+}
+
+// Options issues an OPTIONS HTTP verb to the given route.
+func (r *Request) Options(ctx context.Context, route string) *Response {
+	return r.send(ctx, http.MethodOptions, route, nil)
+}
+
+// send issues the request with the collected options and headers.
+func (r *Request) send(ctx context.Context, verb string, route string, body any) *Response {
+	opts := append([]RequestOption{WithRoute(route)}, r.options...)
+
+	return r.client.newRequest(WithOptions(ctx, opts...), verb, route, body, r.headers)
+}
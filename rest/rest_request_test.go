@@ -0,0 +1,136 @@
+package rest_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arielsrv/go-restclient/rest"
+)
+
+// routeRecorder is a transport that records the route template of each request.
+type routeRecorder struct {
+	transport http.RoundTripper
+	routes    []string
+}
+
+func (r *routeRecorder) RoundTrip(request *http.Request) (*http.Response, error) {
+	r.routes = append(r.routes, rest.RouteFromContext(request.Context()))
+	return r.transport.RoundTrip(request)
+}
+
+func TestRequest_Builder(t *testing.T) {
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/users/john%2Fdoe%20jr/orders", r.URL.EscapedPath())
+		assert.Equal(t, "filter=a%26b&page=2&page=3&sort=name", r.URL.RawQuery)
+		assert.Equal(t, "acme", r.Header.Get("X-Tenant"))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":1,"name":"John"}`))
+	}))
+
+	recorder := &routeRecorder{transport: http.DefaultTransport}
+	client := &rest.Client{
+		BaseURL:    srv.URL + "/api/v1/",
+		CustomPool: &rest.CustomPool{Transport: recorder},
+	}
+
+	response := client.R().
+		PathParam("id", "john/doe jr").
+		Query("page", 2, 3).
+		Query("filter", "a&b").
+		Header("X-Tenant", "acme").
+		Get(t.Context(), "/users/{id}/orders?sort=name")
+
+	require.NoError(t, response.Err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, []string{"/users/{id}/orders"}, recorder.routes)
+
+	// A dot segment cannot escape the route
+	response = client.R().PathParam("id", "..").Get(t.Context(), "/users/{id}/orders")
+	require.ErrorIs(t, response.Err, rest.ErrInvalidPathParam)
+	assert.Len(t, recorder.routes, 1)
+}
+
+func TestRequest_Builder_Post(t *testing.T) {
+	client := &rest.Client{BaseURL: server.URL}
+
+	response := client.R().Post(t.Context(), "user", &User{Name: "Maria"})
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+
+	response = client.R().PathParams(map[string]string{"id": "3"}).Put(t.Context(), "/user/{id}", &User{Name: "Pichucha"})
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	response = client.R().PathParam("id", "3").Patch(t.Context(), "/user/{id}", &User{Name: "Pichucha"})
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	response = client.R().PathParam("id", "4").Delete(t.Context(), "/user/{id}")
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	response = client.R().Head(t.Context(), "/user")
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	response = client.R().Options(t.Context(), "/user")
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestRequest_Builder_Timeout(t *testing.T) {
+	client := &rest.Client{BaseURL: server.URL, DisableTimeout: true}
+
+	response := client.R().Timeout(10*time.Millisecond).Get(t.Context(), "/slow/user")
+	require.Error(t, response.Err)
+	require.ErrorIs(t, response.Err, context.DeadlineExceeded)
+}
+
+func TestWithOptions_HTTPClient(t *testing.T) {
+	var httpClient rest.HTTPClient = &rest.Client{BaseURL: server.URL}
+
+	ctx := rest.WithOptions(t.Context(), rest.WithPathParam("id", "1"))
+	response := httpClient.GetWithContext(ctx, "/user/{id}")
+	require.NoError(t, response.Err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	assert.True(t, strings.HasSuffix(response.Request.URL.Path, "/user/1"))
+}
+
+// nestedTransport sends a request with the client from within each request it carries,
+// using its context, as a token fetching Auth provider would.
+type nestedTransport struct {
+	client *rest.Client
+	nested *rest.Response
+}
+
+func (r *nestedTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	r.nested = r.client.GetWithContext(request.Context(), "/user")
+	return http.DefaultTransport.RoundTrip(request)
+}
+
+func TestWithOptions_Nested(t *testing.T) {
+	nested := &nestedTransport{client: &rest.Client{BaseURL: server.URL}}
+	client := &rest.Client{BaseURL: server.URL, CustomPool: &rest.CustomPool{Transport: nested}}
+
+	ctx := rest.WithOptions(t.Context(), rest.WithQuery("page", 2), rest.WithIdempotencyKey("key"))
+	response := client.R().PathParam("id", "1").Get(ctx, "/user/{id}")
+	require.NoError(t, response.Err)
+	assert.Equal(t, "page=2", response.Request.URL.RawQuery)
+	assert.Equal(t, "key", response.Request.Header.Get(rest.IdempotencyKeyHeader))
+
+	// The options of the enclosing request do not apply to the nested one
+	require.NoError(t, nested.nested.Err)
+	assert.Empty(t, nested.nested.Request.URL.RawQuery)
+	assert.Empty(t, nested.nested.Request.Header.Get(rest.IdempotencyKeyHeader))
+
+	// They still apply to the next request made with ctx
+	response = client.GetWithContext(ctx, "/user")
+	assert.Equal(t, "page=2", response.Request.URL.RawQuery)
+}
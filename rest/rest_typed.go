@@ -0,0 +1,113 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// GetJSON issues a GET HTTP verb to the specified URL using the given client and
+// deserializes the response body into a new value of type T.
+//
+// Non-2xx responses are returned as a *StatusError carrying the RFC7807 problem details,
+// if any, wrapped in a *ConflictError for 412 (Precondition Failed). The *Response is
+// always returned when available, so that headers and the raw body can still be
+// inspected. Any HTTPClient implementation can be used, including mocks.
+//
+// Example usage:
+//
+//	user, _, err := rest.GetJSON[User](ctx, client, "/users/1")
+func GetJSON[T any](ctx context.Context, client HTTPClient, url string, headers ...http.Header) (T, *Response, error) {
+	return decodeResponse[T](client.GetWithContext(ctx, url, headers...))
+}
+
+// PostJSON issues a POST HTTP verb to the specified URL using the given client and
+// deserializes the response body into a new value of type Resp.
+//
+// The body is marshaled according to the client's ContentType. Errors follow the same
+// rules as GetJSON.
+//
+// Example usage:
+//
+//	created, _, err := rest.PostJSON[CreateUser, User](ctx, client, "/users", CreateUser{Name: "Maria"})
+func PostJSON[Req, Resp any](
+	ctx context.Context,
+	client HTTPClient,
+	url string,
+	body Req,
+	headers ...http.Header,
+) (Resp, *Response, error) {
+	return decodeResponse[Resp](client.PostWithContext(ctx, url, body, headers...))
+}
+
+// PutJSON issues a PUT HTTP verb to the specified URL using the given client and
+// deserializes the response body into a new value of type Resp.
+//
+// Errors follow the same rules as GetJSON.
+func PutJSON[Req, Resp any](
+	ctx context.Context,
+	client HTTPClient,
+	url string,
+	body Req,
+	headers ...http.Header,
+) (Resp, *Response, error) {
+	return decodeResponse[Resp](client.PutWithContext(ctx, url, body, headers...))
+}
+
+// PatchJSON issues a PATCH HTTP verb to the specified URL using the given client and
+// deserializes the response body into a new value of type Resp.
+//
+// Errors follow the same rules as GetJSON.
+func PatchJSON[Req, Resp any](
+	ctx context.Context,
+	client HTTPClient,
+	url string,
+	body Req,
+	headers ...http.Header,
+) (Resp, *Response, error) {
+	return decodeResponse[Resp](client.PatchWithContext(ctx, url, body, headers...))
+}
+
+// DeleteJSON issues a DELETE HTTP verb to the specified URL using the given client and
+// deserializes the response body, if any, into a new value of type T.
+//
+// Errors follow the same rules as GetJSON.
+func DeleteJSON[T any](
+	ctx context.Context,
+	client HTTPClient,
+	url string,
+	headers ...http.Header,
+) (T, *Response, error) {
+	return decodeResponse[T](client.DeleteWithContext(ctx, url, headers...))
+}
+
+// decodeResponse turns a Response into a typed value, a *StatusError for non-2xx
+// responses, or the request error. Empty bodies, such as 204 (No Content), yield the
+// zero value of T.
+func decodeResponse[T any](response *Response) (T, *Response, error) {
+	var dflt T
+	if response == nil {
+		return dflt, nil, errors.New("response is nil")
+	}
+	if response.Err != nil {
+		return dflt, response, response.Err
+	}
+	if response.Response == nil {
+		return dflt, response, errors.New("http.Response is nil")
+	}
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return dflt, response, statusError(response)
+	}
+
+	if response.StatusCode == http.StatusNoContent || len(response.bytes) == 0 {
+		return dflt, response, nil
+	}
+
+	result, err := Deserialize[T](response)
+	if err != nil {
+		return dflt, response, err
+	}
+
+	return result, response, nil
+}
@@ -0,0 +1,108 @@
+package rest_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	mocks "github.com/arielsrv/go-restclient/mocks/rest"
+	"github.com/arielsrv/go-restclient/rest"
+)
+
+func TestGetJSON(t *testing.T) {
+	client := &rest.Client{BaseURL: server.URL}
+
+	result, resp, err := rest.GetJSON[[]User](t.Context(), client, "/user")
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Len(t, result, len(userList))
+}
+
+func TestPostJSON(t *testing.T) {
+	client := &rest.Client{BaseURL: server.URL}
+
+	created, resp, err := rest.PostJSON[User, User](t.Context(), client, "/user", User{Name: "Maria"})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "Maria", created.Name)
+	assert.Equal(t, 3, created.ID)
+}
+
+func TestPutPatchDeleteJSON(t *testing.T) {
+	client := &rest.Client{BaseURL: server.URL}
+
+	updated, _, err := rest.PutJSON[User, User](t.Context(), client, "/user/3", User{Name: "Pichucha"})
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", updated.Name)
+
+	patched, _, err := rest.PatchJSON[User, User](t.Context(), client, "/user/3", User{Name: "Pichucha"})
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", patched.Name)
+
+	deleted, resp, err := rest.DeleteJSON[*User](t.Context(), client, "/user/4")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Nil(t, deleted)
+}
+
+func TestGetJSON_Problem(t *testing.T) {
+	client := &rest.Client{BaseURL: server.URL}
+
+	_, resp, err := rest.GetJSON[User](t.Context(), client, "/problem")
+	require.Error(t, err)
+	require.NotNil(t, resp)
+
+	var statusErr *rest.StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+	require.NotNil(t, statusErr.Problem)
+	assert.Equal(t, "Not Found", statusErr.Problem.Title)
+	assert.Equal(t, statusErr.Problem, resp.Problem)
+}
+
+func TestGetJSON_RequestErr(t *testing.T) {
+	client := &rest.Client{}
+
+	_, resp, err := rest.GetJSON[User](t.Context(), client, "foo")
+	require.Error(t, err)
+	require.NotNil(t, resp)
+}
+
+func TestGetJSON_Mock(t *testing.T) {
+	httpClient := mocks.NewMockHTTPClient(t)
+	httpClient.EXPECT().
+		GetWithContext(mock.Anything, "/users/1").
+		Return(rest.NewResponse(&http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+		}, []byte(`{"id":1,"name":"Alice"}`)))
+
+	user, _, err := rest.GetJSON[User](t.Context(), httpClient, "/users/1")
+	require.NoError(t, err)
+	assert.Equal(t, User{ID: 1, Name: "Alice"}, user)
+}
+
+func TestPostJSON_MockErr(t *testing.T) {
+	expected := errors.New("connection refused")
+
+	httpClient := mocks.NewMockHTTPClient(t)
+	httpClient.EXPECT().
+		PostWithContext(mock.Anything, "/users", User{Name: "Maria"}).
+		Return(&rest.Response{Err: expected})
+
+	_, _, err := rest.PostJSON[User, User](t.Context(), httpClient, "/users", User{Name: "Maria"})
+	require.ErrorIs(t, err, expected)
+}
+
+func TestVerifyIsOkOrError_StatusError(t *testing.T) {
+	resp := rest.Get(server.URL + "/problem")
+
+	var statusErr *rest.StatusError
+	require.ErrorAs(t, resp.VerifyIsOkOrError(), &statusErr)
+	assert.Equal(t, "The requested resource was not found.", statusErr.Problem.Detail)
+}
@@ -0,0 +1,223 @@
+package rest
+
+import (
+	"cmp"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Retry defaults.
+const (
+	// DefaultMaxRetries is the maximum number of retries of a request by default.
+	DefaultMaxRetries = 2
+
+	// DefaultRetryBackoff is the delay before the first retry by default.
+	DefaultRetryBackoff = 50 * time.Millisecond
+
+	// DefaultRetryMaxBackoff is the longest delay between retries by default.
+	DefaultRetryMaxBackoff = time.Second
+
+	// DefaultRetryRatio is the maximum ratio of retries to requests of a RetryBudget by default.
+	DefaultRetryRatio = 0.1
+
+	// DefaultRetryBurst is the number of retries a RetryBudget allows at once by default.
+	DefaultRetryBurst = 10
+)
+
+// retryCost is the price of a retry in budget tokens, so that ratios are summed exactly.
+const retryCost = 1000
+
+// retryStatusCodes are the statuses of transient failures worth retrying.
+var retryStatusCodes = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+// Retry retries the requests of a Client failing with a transport error or a 502, 503 or
+// 504 status. Permanent transport errors, such as invalid TLS or proxy settings, pin
+// mismatches and certificate verification failures, are not retried. Only idempotent
+// requests (GET, HEAD, OPTIONS, PUT and DELETE, or any request with an Idempotency-Key)
+// whose body can be replayed are retried, after an exponential backoff with full jitter,
+// and never once the request context is done.
+//
+// Naive retries amplify outages, so Budget caps them; share it between the clients of the
+// same upstream.
+//
+// Example usage:
+//
+//	budget := &rest.RetryBudget{Ratio: 0.1}
+//
+//	client := &rest.Client{
+//	    BaseURL: "https://api.example.com",
+//	    Retry: &rest.Retry{
+//	        MaxRetries: 3,
+//	        Budget:     budget,
+//	    },
+//	}
+type Retry struct {
+	// Budget caps the retries to a ratio of the requests. Retries are unbounded by default.
+	Budget *RetryBudget
+	// MaxRetries is the maximum number of retries of a request, DefaultMaxRetries by default.
+	MaxRetries int
+	// Backoff is the delay before the first retry, doubled on every retry,
+	// DefaultRetryBackoff by default.
+	Backoff time.Duration
+	// MaxBackoff is the longest delay between retries, DefaultRetryMaxBackoff by default.
+	MaxBackoff time.Duration
+}
+
+// RetryBudget is a token bucket capping retries to a ratio of recent requests, so retries
+// do not pile up on a failing upstream. Every request adds Ratio of a token, up to Burst
+// tokens, and every retry takes one. When the budget is exhausted, failures are returned
+// right away and the rest.client.retry_budget.exhausted counter of the OpenTelemetry meter
+// provider is incremented.
+//
+// A RetryBudget can be shared by several clients hitting the same upstream.
+type RetryBudget struct {
+	// Ratio is the maximum ratio of retries to requests, DefaultRetryRatio by default.
+	Ratio float64
+	// Burst is the number of retries allowed at once, and the initial budget,
+	// DefaultRetryBurst by default.
+	Burst     int
+	tokens    int
+	exhausted atomic.Int64
+	once      sync.Once
+	mtx       sync.Mutex
+}
+
+// retryBudgetExhausted counts the retries denied by a RetryBudget.
+var retryBudgetExhausted = sync.OnceValue(func() metric.Int64Counter {
+	counter, _ := otel.Meter("github.com/arielsrv/go-restclient/rest").Int64Counter(
+		"rest.client.retry_budget.exhausted",
+		metric.WithDescription("Retries denied because the retry budget was exhausted"),
+		metric.WithUnit("{retry}"),
+	)
+	return counter
+})
+
+// Exhausted returns the number of retries denied because the budget was exhausted.
+func (r *RetryBudget) Exhausted() int64 {
+	return r.exhausted.Load()
+}
+
+// init fills the bucket. It must be called with the lock held.
+func (r *RetryBudget) init() {
+	r.once.Do(func() {
+		r.tokens = cmp.Or(r.Burst, DefaultRetryBurst) * retryCost
+	})
+}
+
+// deposit adds the share of a retry paid by a request.
+func (r *RetryBudget) deposit() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.init()
+	share := int(cmp.Or(r.Ratio, DefaultRetryRatio) * retryCost)
+	r.tokens = min(r.tokens+share, cmp.Or(r.Burst, DefaultRetryBurst)*retryCost)
+}
+
+// withdraw takes a retry from the budget, reporting whether there was one.
+func (r *RetryBudget) withdraw(ctx context.Context, client string) bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.init()
+	if r.tokens < retryCost {
+		r.exhausted.Add(1)
+		retryBudgetExhausted().Add(ctx, 1, metric.WithAttributes(attribute.String("rest.client", client)))
+		return false
+	}
+	r.tokens -= retryCost
+
+	return true
+}
+
+// retryTransport retries failed idempotent requests as configured by a Retry.
+type retryTransport struct {
+	Transport http.RoundTripper
+	retry     *Retry
+	client    *Client
+}
+
+// RoundTrip sends the request, sending it again after a backoff while it fails with a
+// transient error and the retry budget allows it.
+func (r *retryTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if r.retry.Budget != nil {
+		r.retry.Budget.deposit()
+	}
+
+	ctx := request.Context()
+	backoff := cmp.Or(r.retry.Backoff, DefaultRetryBackoff)
+	attempt := request
+	for retries := 0; ; retries++ {
+		response, err := r.Transport.RoundTrip(attempt)
+		if !retryable(request, response, err) || retries >= cmp.Or(r.retry.MaxRetries, DefaultMaxRetries) {
+			return withRequest(response, request), err
+		}
+
+		next, ok := rewind(request)
+		if !ok || (r.retry.Budget != nil && !r.retry.Budget.withdraw(ctx, r.client.Name)) {
+			return withRequest(response, request), err
+		}
+
+		// The failed response is dropped, reading it to reuse the connection
+		if response != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 4<<10))
+			_ = response.Body.Close()
+		}
+
+		timer := time.NewTimer(rand.N(backoff) + 1)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			closeBody(next)
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		attempt = next
+		backoff = min(backoff*2, cmp.Or(r.retry.MaxBackoff, DefaultRetryMaxBackoff))
+	}
+}
+
+// retryable reports whether the request failed with a transient error and may be sent
+// again: it is idempotent, or carries an Idempotency-Key, and its context is not done.
+func retryable(request *http.Request, response *http.Response, err error) bool {
+	idempotent := slices.Contains(idempotentVerbs, request.Method) || request.Header.Get(IdempotencyKeyHeader) != ""
+	if !idempotent || request.Context().Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		return !permanent(err)
+	}
+
+	return slices.Contains(retryStatusCodes, response.StatusCode)
+}
+
+// permanent reports whether the transport error fails every attempt the same way: an
+// invalid client setup, a pin mismatch or a server certificate failing verification.
+func permanent(err error) bool {
+	var (
+		setupErr     *setupError
+		pinErr       *PinError
+		verifyErr    *tls.CertificateVerificationError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+
+	return errors.As(err, &setupErr) || errors.As(err, &pinErr) || errors.As(err, &verifyErr) ||
+		errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr)
+}
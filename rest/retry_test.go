@@ -0,0 +1,194 @@
+package rest_test
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arielsrv/go-restclient/rest"
+)
+
+// flakyHandler fails the first failures requests with the status, or by dropping the
+// connection when the status is zero, and counts the requests.
+func flakyHandler(t *testing.T, failures int32, status int) (http.HandlerFunc, *atomic.Int32) {
+	hits := new(atomic.Int32)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) > failures {
+			body, _ := io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(body)
+			return
+		}
+
+		if status == 0 {
+			conn, _, err := http.NewResponseController(w).Hijack()
+			require.NoError(t, err)
+			_ = conn.Close()
+			return
+		}
+		w.WriteHeader(status)
+	}, hits
+}
+
+func TestRetry_TransientFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{name: "service unavailable", status: http.StatusServiceUnavailable},
+		{name: "bad gateway", status: http.StatusBadGateway},
+		{name: "connection dropped"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, hits := flakyHandler(t, 2, tt.status)
+			srv := newServer(t, handler)
+			client := &rest.Client{
+				BaseURL: srv.URL,
+				Retry:   &rest.Retry{MaxRetries: 3, Backoff: time.Millisecond},
+			}
+
+			response := client.PutWithContext(t.Context(), "/users/1", &User{ID: 1, Name: "john"})
+			require.NoError(t, response.Err)
+			assert.Equal(t, http.StatusOK, response.StatusCode)
+			assert.JSONEq(t, `{"id":1,"name":"john"}`, response.String())
+			assert.Equal(t, int32(3), hits.Load())
+		})
+	}
+}
+
+func TestRetry_MaxRetries(t *testing.T) {
+	handler, hits := flakyHandler(t, 10, http.StatusServiceUnavailable)
+	srv := newServer(t, handler)
+	client := &rest.Client{
+		BaseURL: srv.URL,
+		Retry:   &rest.Retry{MaxRetries: 2, Backoff: time.Millisecond},
+	}
+
+	response := client.GetWithContext(t.Context(), "/user")
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.Equal(t, int32(3), hits.Load())
+}
+
+func TestRetry_UnsafeVerbs(t *testing.T) {
+	handler, hits := flakyHandler(t, 1, http.StatusServiceUnavailable)
+	srv := newServer(t, handler)
+	client := &rest.Client{
+		BaseURL: srv.URL,
+		Retry:   &rest.Retry{Backoff: time.Millisecond},
+	}
+
+	response := client.PostWithContext(t.Context(), "/users", &User{ID: 1})
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.Equal(t, int32(1), hits.Load())
+}
+
+func TestRetry_Budget(t *testing.T) {
+	handler, hits := flakyHandler(t, 100, http.StatusServiceUnavailable)
+	srv := newServer(t, handler)
+
+	// The budget is shared by both clients of the upstream
+	budget := &rest.RetryBudget{Ratio: 0.5, Burst: 1}
+	retry := &rest.Retry{MaxRetries: 3, Backoff: time.Millisecond, Budget: budget}
+	first := &rest.Client{BaseURL: srv.URL, Retry: retry}
+	second := &rest.Client{BaseURL: srv.URL, Retry: retry}
+
+	// The burst pays for one retry, then the failure is returned right away
+	response := first.GetWithContext(t.Context(), "/user")
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.Equal(t, int32(2), hits.Load())
+	assert.Equal(t, int64(1), budget.Exhausted())
+
+	// Half a retry is earned per request
+	response = second.GetWithContext(t.Context(), "/user")
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.Equal(t, int32(3), hits.Load())
+	assert.Equal(t, int64(2), budget.Exhausted())
+
+	response = first.GetWithContext(t.Context(), "/user")
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.Equal(t, int32(5), hits.Load())
+	assert.Equal(t, int64(3), budget.Exhausted())
+}
+
+func TestRetry_PermanentErrors(t *testing.T) {
+	var conns atomic.Int32
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), func(srv *httptest.Server) {
+		srv.TLS = new(tls.Config)
+		srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				conns.Add(1)
+			}
+		}
+	})
+
+	pin := "sha256/" + base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+	rootCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+
+	tests := []struct {
+		tls     *rest.TLSConfig
+		target  any
+		name    string
+		dialed  int32
+		wantErr error
+	}{
+		{
+			name:   "unknown authority",
+			tls:    &rest.TLSConfig{},
+			target: new(*tls.CertificateVerificationError),
+			dialed: 1,
+		},
+		{
+			name:   "pin mismatch",
+			tls:    &rest.TLSConfig{RootCAs: [][]byte{rootCA}, Pins: []string{pin}},
+			target: new(*rest.PinError),
+			dialed: 1,
+		},
+		{
+			name:    "setup",
+			tls:     &rest.TLSConfig{CertFile: "missing.crt", KeyFile: "missing.key"},
+			wantErr: os.ErrNotExist,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conns.Store(0)
+			client := &rest.Client{
+				BaseURL: srv.URL,
+				TLS:     tt.tls,
+				Retry:   &rest.Retry{MaxRetries: 3, Backoff: time.Second},
+			}
+
+			start := time.Now()
+			response := client.GetWithContext(t.Context(), "/")
+			if tt.wantErr != nil {
+				require.ErrorIs(t, response.Err, tt.wantErr)
+			} else {
+				require.ErrorAs(t, response.Err, tt.target)
+			}
+
+			// Failed once, without waiting for a retry
+			assert.Less(t, time.Since(start), 500*time.Millisecond)
+			assert.Equal(t, tt.dialed, conns.Load())
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package rest
+
+import (
+	"net/url"
+	"testing"
+)
+
+func Test_awsCanonicalQuery_sortsByKeyThenValue(t *testing.T) {
+	tests := []struct {
+		query    url.Values
+		expected string
+	}{
+		{query: url.Values{"page2": {"1"}, "page": {"1"}}, expected: "page=1&page2=1"},
+		{query: url.Values{"a-b": {"x"}, "a": {"y"}}, expected: "a=y&a-b=x"},
+		{query: url.Values{"a.b": {"1"}, "a": {"2", "10"}}, expected: "a=10&a=2&a.b=1"},
+		{query: url.Values{"key": {"b c", "a"}}, expected: "key=a&key=b%20c"},
+	}
+
+	for _, tt := range tests {
+		if got := awsCanonicalQuery(tt.query); got != tt.expected {
+			t.Errorf("awsCanonicalQuery(%v) = %q, want %q", tt.query, got, tt.expected)
+		}
+	}
+}
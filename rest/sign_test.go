@@ -0,0 +1,266 @@
+package rest_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arielsrv/go-restclient/rest"
+)
+
+func TestSigV4Signer(t *testing.T) {
+	// Example request of the AWS Signature Version 4 documentation
+	request, err := http.NewRequestWithContext(t.Context(), http.MethodGet,
+		"https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	signer := &rest.SigV4Signer{
+		Now:             func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) },
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Region:          "us-east-1",
+		Service:         "iam",
+	}
+	require.NoError(t, signer.Sign(request, nil))
+
+	assert.Equal(t, "20150830T123600Z", request.Header.Get("X-Amz-Date"))
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, "+
+		"SignedHeaders=content-type;host;x-amz-date, "+
+		"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7",
+		request.Header.Get("Authorization"))
+}
+
+func TestSigV4Signer_S3(t *testing.T) {
+	request, err := http.NewRequestWithContext(t.Context(), http.MethodPut,
+		"https://bucket.storage.example.com/my%20file.txt", strings.NewReader("hello"))
+	require.NoError(t, err)
+
+	signer := &rest.SigV4Signer{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+		SessionToken:    "session",
+		Region:          "us-east-1",
+		Service:         "s3",
+	}
+	require.NoError(t, signer.Sign(request, []byte("hello")))
+
+	sum := sha256.Sum256([]byte("hello"))
+	assert.Equal(t, hex.EncodeToString(sum[:]), request.Header.Get("X-Amz-Content-Sha256"))
+	assert.Equal(t, "session", request.Header.Get("X-Amz-Security-Token"))
+	assert.Contains(t, request.Header.Get("Authorization"),
+		"SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-security-token,")
+}
+
+func TestSigV4Signer_EscapedPath(t *testing.T) {
+	sign := func(service, rawURL string) string {
+		request, err := http.NewRequestWithContext(t.Context(), http.MethodGet, rawURL, nil)
+		require.NoError(t, err)
+
+		signer := &rest.SigV4Signer{
+			Now:             func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) },
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "secret",
+			Region:          "us-east-1",
+			Service:         service,
+		}
+		require.NoError(t, signer.Sign(request, nil))
+
+		return request.Header.Get("Authorization")
+	}
+
+	for _, service := range []string{"s3", "execute-api"} {
+		t.Run(service, func(t *testing.T) {
+			// An escaped slash is sent as is, and signed as sent
+			assert.NotEqual(t, sign(service, "https://example.com/docs/a%2Fb"), sign(service, "https://example.com/docs/a/b"))
+			assert.Equal(t, sign(service, "https://example.com/my%20file.txt"), sign(service, "https://example.com/my file.txt"))
+		})
+	}
+}
+
+// verifyHMAC recomputes the HMACSigner signature of a received request.
+func verifyHMAC(t *testing.T, r *http.Request, key []byte) bool {
+	t.Helper()
+
+	body, err := io.ReadAll(r.Body)
+	require.NoError(t, err)
+
+	sum := sha256.Sum256(body)
+	digest := base64.StdEncoding.EncodeToString(sum[:])
+	if len(body) > 0 {
+		assert.Equal(t, "sha-256=:"+digest+":", r.Header.Get("Content-Digest"))
+		assert.Equal(t, "SHA-256="+digest, r.Header.Get("Digest"))
+	}
+
+	canonical := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		r.URL.Query().Encode(),
+		"host:" + r.Host,
+		"date:" + r.Header.Get("Date"),
+		"content-type:" + r.Header.Get("Content-Type"),
+		"content-digest:" + r.Header.Get("Content-Digest"),
+		hex.EncodeToString(sum[:]),
+	}, "\n")
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(canonical))
+	expected := "HMAC-SHA256 KeyId=partner, SignedHeaders=host;date;content-type;content-digest, Signature=" +
+		base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	return r.Header.Get("X-Signature") == expected
+}
+
+func TestHMACSigner(t *testing.T) {
+	key := []byte("shared-secret")
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !verifyHMAC(t, r, key) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	client := &rest.Client{
+		BaseURL:     srv.URL,
+		ContentType: rest.JSON,
+		Signer:      &rest.HMACSigner{KeyID: "partner", Key: key, Header: "X-Signature"},
+	}
+
+	response := client.PostWithContext(t.Context(), "/orders?sort=asc&page=1", User{Name: "Maria"})
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.NotContains(t, response.Debug(), "X-Signature")
+}
+
+func TestSigner_AuthorizationSet(t *testing.T) {
+	tests := []struct {
+		signer rest.Signer
+		name   string
+	}{
+		{name: "hmac", signer: &rest.HMACSigner{KeyID: "partner", Key: []byte("shared-secret")}},
+		{
+			name: "sigv4",
+			signer: &rest.SigV4Signer{
+				AccessKeyID:     "AKIDEXAMPLE",
+				SecretAccessKey: "secret",
+				Region:          "us-east-1",
+				Service:         "execute-api",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &rest.Client{
+				BaseURL:   server.URL,
+				BasicAuth: &rest.BasicAuth{Username: "user", Password: "secret"},
+				Signer:    tt.signer,
+			}
+
+			// The Basic credentials are not overwritten by the signature
+			response := client.GetWithContext(t.Context(), "/user")
+			require.ErrorIs(t, response.Err, rest.ErrSignatureHeaderSet)
+		})
+	}
+
+	// A dedicated header keeps both
+	client := &rest.Client{
+		BaseURL:   server.URL,
+		BasicAuth: &rest.BasicAuth{Username: "user", Password: "secret"},
+		Signer:    &rest.HMACSigner{KeyID: "partner", Key: []byte("shared-secret"), Header: "X-Signature"},
+	}
+
+	response := client.GetWithContext(t.Context(), "/user")
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestSigner_ResignedOnRetry(t *testing.T) {
+	key := []byte("shared-secret")
+	var calls atomic.Int32
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !verifyHMAC(t, r, key) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	var fetched atomic.Int32
+	client := &rest.Client{
+		BaseURL:     srv.URL,
+		ContentType: rest.JSON,
+		Auth: rest.BearerTokenFunc(func(context.Context) (string, error) {
+			if fetched.Add(1) == 1 {
+				return "token-1", nil
+			}
+			return "token-2", nil
+		}),
+		Signer: &rest.HMACSigner{KeyID: "partner", Key: key, Header: "X-Signature"},
+	}
+
+	response := client.PostWithContext(t.Context(), "/orders", User{Name: "Maria"})
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestSigner_Mockup(t *testing.T) {
+	defer rest.StopMockupServer()
+	rest.StartMockupServer()
+
+	myURL := "https://partner.example.com/orders?page=1"
+	err := rest.AddMockups(&rest.Mock{
+		URL:          myURL,
+		HTTPMethod:   http.MethodGet,
+		RespHTTPCode: http.StatusOK,
+		RespBody:     "foo",
+	})
+	require.NoError(t, err)
+
+	var signed atomic.Bool
+	client := &rest.Client{
+		Signer: rest.SignerFunc(func(request *http.Request, body []byte) error {
+			assert.Equal(t, "https", request.URL.Scheme)
+			assert.Equal(t, "partner.example.com", request.Host)
+			assert.Equal(t, "/orders", request.URL.Path)
+			assert.Empty(t, body)
+			signed.Store(true)
+			return nil
+		}),
+	}
+
+	response := client.GetWithContext(t.Context(), myURL)
+	require.NoError(t, response.Err)
+	assert.Equal(t, "foo", response.String())
+	assert.True(t, signed.Load())
+}
+
+func TestSigner_Err(t *testing.T) {
+	client := &rest.Client{
+		BaseURL: server.URL,
+		Signer: rest.SignerFunc(func(*http.Request, []byte) error {
+			return io.ErrUnexpectedEOF
+		}),
+	}
+
+	response := client.GetWithContext(t.Context(), "/user")
+	require.ErrorIs(t, response.Err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,296 @@
+package rest
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultEventRetry is the reconnection delay used for Server-Sent Events streams
+// until the server sends a retry field.
+var DefaultEventRetry = 3 * time.Second
+
+// ErrStreamIdleTimeout is the cause reported when a Server-Sent Events stream
+// exceeds the client's StreamIdleTimeout without receiving data.
+var ErrStreamIdleTimeout = errors.New("stream idle timeout exceeded")
+
+// Event represents a Server-Sent Event as defined by the WHATWG HTML Living Standard.
+// See https://html.spec.whatwg.org/multipage/server-sent-events.html
+type Event struct {
+	// ID is the last event ID seen on the stream when this event was dispatched.
+	ID string
+
+	// Event is the event type, "message" when the server does not name it.
+	Event string
+
+	// Data is the event payload. Multiple data lines are joined with "\n".
+	Data string
+
+	// Retry is the reconnection delay sent along with this event, if any.
+	Retry time.Duration
+}
+
+// Events issues a GET HTTP verb to the specified URL and consumes the response as a
+// Server-Sent Events (text/event-stream) stream, yielding one Event per dispatch.
+//
+// The request goes through the same pipeline as any other call, so authentication,
+// DefaultHeaders, tracing and timeouts apply. When the connection drops, Events waits
+// for the reconnection delay (DefaultEventRetry or the server's retry field) and
+// reconnects, sending the Last-Event-ID header so the server can resume. Connection
+// errors are yielded before reconnecting; stop iterating to give up.
+//
+// The iteration ends without error when the server answers 204 (No Content) and with
+// a final error on any other non-2xx status, an unexpected content type or when ctx
+// is done. StreamIdleTimeout, instead of the client's Timeout, bounds the wait for the
+// response headers, and then how long the stream may stay silent between events.
+//
+// Example usage:
+//
+//	for event, err := range client.Events(ctx, "/notifications") {
+//	    if err != nil {
+//	        log.Println(err)
+//	        continue
+//	    }
+//	    fmt.Println(event.Event, event.Data)
+//	}
+func (r *Client) Events(ctx context.Context, url string, headers ...http.Header) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		source := &eventSource{
+			lastEventID: "",
+			retry:       DefaultEventRetry,
+		}
+
+		for {
+			done, err := r.consumeEvents(ctx, url, source, yield, headers...)
+			if done {
+				return
+			}
+
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				yield(Event{}, ctxErr)
+				return
+			}
+
+			if err != nil && !yield(Event{}, err) {
+				return
+			}
+
+			timer := time.NewTimer(source.retry)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				yield(Event{}, ctx.Err())
+				return
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+// eventSource keeps the state of a Server-Sent Events stream across reconnections.
+type eventSource struct {
+	lastEventID string
+	retry       time.Duration
+}
+
+// consumeEvents opens a single Server-Sent Events connection and yields its events.
+// It returns done when the iteration must end, otherwise the error that broke the
+// connection (nil on a clean end of stream) so that Events can reconnect.
+func (r *Client) consumeEvents(
+	ctx context.Context,
+	url string,
+	source *eventSource,
+	yield func(Event, error) bool,
+	headers ...http.Header,
+) (bool, error) {
+	connCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	if source.lastEventID != "" {
+		headers = append(headers, http.Header{LastEventIDHeader: []string{source.lastEventID}})
+	}
+
+	var idle *idleReader
+	if r.StreamIdleTimeout > 0 {
+		idle = newIdleReader(r.StreamIdleTimeout, cancel)
+		defer idle.stop()
+	}
+
+	streamCtx := WithOptions(connCtx, func(options *requestOptions) {
+		options.eventStream = true
+	})
+	response, err := r.stream(streamCtx, http.MethodGet, url, nil, MIMETextEventStream, headers...)
+	if err != nil {
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) {
+			yield(Event{}, err)
+			return true, nil
+		}
+		if cause := context.Cause(connCtx); errors.Is(cause, ErrStreamIdleTimeout) {
+			return false, cause
+		}
+		return false, err
+	}
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(response.Body)
+
+	if response.StatusCode == http.StatusNoContent {
+		return true, nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(response.Header.Get(CanonicalContentTypeHeader))
+	if err != nil || mediaType != MIMETextEventStream {
+		yield(Event{}, fmt.Errorf("stream fail, unexpected content type: %s",
+			response.Header.Get(CanonicalContentTypeHeader)))
+		return true, nil
+	}
+
+	var body io.Reader = response.Body
+	if idle != nil {
+		body = idle.wrap(response.Body)
+	}
+
+	for event, rErr := range readEvents(body, source) {
+		if rErr != nil {
+			if cause := context.Cause(connCtx); errors.Is(cause, ErrStreamIdleTimeout) {
+				return false, cause
+			}
+			return false, rErr
+		}
+
+		if !yield(event, nil) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// readEvents parses a text/event-stream body and yields the dispatched events,
+// updating the last event ID and reconnection delay of the source as it goes.
+// A read error other than io.EOF is yielded once and ends the iteration.
+func readEvents(body io.Reader, source *eventSource) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		reader := bufio.NewReader(body)
+
+		var (
+			data      strings.Builder
+			hasData   bool
+			eventType string
+			retry     time.Duration
+		)
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				// An incomplete event at the end of the stream is discarded.
+				if !errors.Is(err, io.EOF) {
+					yield(Event{}, err)
+				}
+				return
+			}
+
+			line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+			if line == "" {
+				if hasData {
+					event := Event{
+						ID:    source.lastEventID,
+						Event: eventType,
+						Data:  data.String(),
+						Retry: retry,
+					}
+					if event.Event == "" {
+						event.Event = "message"
+					}
+					if !yield(event, nil) {
+						return
+					}
+				}
+
+				data.Reset()
+				hasData, eventType, retry = false, "", 0
+				continue
+			}
+
+			if strings.HasPrefix(line, ":") {
+				continue
+			}
+
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+
+			switch field {
+			case "event":
+				eventType = value
+			case "data":
+				if hasData {
+					data.WriteByte('\n')
+				}
+				data.WriteString(value)
+				hasData = true
+			case "id":
+				if !strings.ContainsRune(value, 0) {
+					source.lastEventID = value
+				}
+			case "retry":
+				if millis, pErr := strconv.ParseUint(value, 10, 63); pErr == nil {
+					retry = time.Duration(millis) * time.Millisecond
+					source.retry = retry
+				}
+			}
+		}
+	}
+}
+
+// idleReader cancels a stream when no data is read within the configured timeout.
+type idleReader struct {
+	reader  io.Reader
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+// newIdleReader starts the idle timer, so that cancel is called with
+// ErrStreamIdleTimeout once timeout elapses without response headers or, after wrap,
+// without a successful read.
+func newIdleReader(timeout time.Duration, cancel context.CancelCauseFunc) *idleReader {
+	return &idleReader{
+		timeout: timeout,
+		timer: time.AfterFunc(timeout, func() {
+			cancel(ErrStreamIdleTimeout)
+		}),
+	}
+}
+
+// wrap reads the response body through the idle reader, restarting the idle timer as
+// the response headers have arrived.
+func (r *idleReader) wrap(reader io.Reader) io.Reader {
+	r.reader = reader
+	r.timer.Reset(r.timeout)
+
+	return r
+}
+
+// Read reads from the underlying reader and restarts the idle timer when data arrives.
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.timer.Reset(r.timeout)
+	}
+
+	return n, err
+}
+
+// stop releases the idle timer.
+func (r *idleReader) stop() {
+	r.timer.Stop()
+}
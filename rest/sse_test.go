@@ -0,0 +1,204 @@
+package rest_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arielsrv/go-restclient/rest"
+)
+
+func TestEvents(t *testing.T) {
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, rest.MIMETextEventStream, r.Header.Get("Accept"))
+		assert.Equal(t, "test", r.Header.Get("X-Default-Test"))
+
+		w.Header().Set("Content-Type", rest.MIMETextEventStream+"; charset=utf-8")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		fmt.Fprint(w, "id: 1\nevent: progress\ndata: {\"done\":10}\n\n")
+		fmt.Fprint(w, "id: 2\r\ndata: line one\r\ndata: line two\r\nretry: 1500\r\n\r\n")
+		fmt.Fprint(w, "event: ignored\n\n")
+		fmt.Fprint(w, "data: incomplete")
+	}))
+
+	client := &rest.Client{
+		BaseURL:        srv.URL,
+		DefaultHeaders: http.Header{"X-Default-Test": {"test"}},
+	}
+
+	var events []rest.Event
+	for event, err := range client.Events(t.Context(), "/events") {
+		require.NoError(t, err)
+		events = append(events, event)
+		if len(events) == 2 {
+			break
+		}
+	}
+
+	require.Len(t, events, 2)
+	assert.Equal(t, rest.Event{ID: "1", Event: "progress", Data: `{"done":10}`}, events[0])
+	assert.Equal(t, rest.Event{
+		ID:    "2",
+		Event: "message",
+		Data:  "line one\nline two",
+		Retry: 1500 * time.Millisecond,
+	}, events[1])
+}
+
+func TestEvents_Reconnect(t *testing.T) {
+	var connections atomic.Int32
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", rest.MIMETextEventStream)
+		switch connections.Add(1) {
+		case 1:
+			assert.Empty(t, r.Header.Get("Last-Event-ID"))
+			fmt.Fprint(w, "retry: 10\nid: 41\ndata: first\n\n")
+		case 2:
+			assert.Equal(t, "41", r.Header.Get("Last-Event-ID"))
+			fmt.Fprint(w, "id: 42\ndata: second\n\n")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+
+	client := &rest.Client{BaseURL: srv.URL}
+
+	var data []string
+	for event, err := range client.Events(t.Context(), "/events") {
+		require.NoError(t, err)
+		data = append(data, event.Data)
+	}
+
+	assert.Equal(t, []string{"first", "second"}, data)
+	assert.Equal(t, int32(3), connections.Load())
+}
+
+func TestEvents_StatusErr(t *testing.T) {
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+
+	client := &rest.Client{BaseURL: srv.URL}
+
+	calls := 0
+	for _, err := range client.Events(t.Context(), "/events") {
+		calls++
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "401")
+	}
+	assert.Equal(t, 1, calls)
+}
+
+func TestEvents_ContentTypeErr(t *testing.T) {
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", rest.MIMEApplicationJSON)
+		fmt.Fprint(w, `{}`)
+	}))
+
+	client := &rest.Client{BaseURL: srv.URL}
+
+	for _, err := range client.Events(t.Context(), "/events") {
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unexpected content type")
+	}
+}
+
+func TestEvents_IdleTimeout(t *testing.T) {
+	var connections atomic.Int32
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", rest.MIMETextEventStream)
+		if connections.Add(1) > 1 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		fmt.Fprint(w, "retry: 1\ndata: hello\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+
+	client := &rest.Client{
+		BaseURL:           srv.URL,
+		StreamIdleTimeout: 50 * time.Millisecond,
+	}
+
+	var errs []error
+	for _, err := range client.Events(t.Context(), "/events") {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	require.Len(t, errs, 1)
+	require.ErrorIs(t, errs[0], rest.ErrStreamIdleTimeout)
+	assert.Equal(t, int32(2), connections.Load())
+}
+
+func TestEvents_HeaderTimeout(t *testing.T) {
+	var connections atomic.Int32
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", rest.MIMETextEventStream)
+		switch connections.Add(1) {
+		case 1:
+			// Slower than the client's Timeout, within its StreamIdleTimeout
+			time.Sleep(200 * time.Millisecond)
+			fmt.Fprint(w, "retry: 1\ndata: hello\n\n")
+		case 2:
+			// Silent for longer than the StreamIdleTimeout
+			<-r.Context().Done()
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+
+	client := &rest.Client{
+		BaseURL:           srv.URL,
+		Timeout:           50 * time.Millisecond,
+		StreamIdleTimeout: 500 * time.Millisecond,
+	}
+
+	var (
+		events []rest.Event
+		errs   []error
+	)
+	for event, err := range client.Events(t.Context(), "/events") {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		events = append(events, event)
+	}
+
+	require.Len(t, events, 1)
+	assert.Equal(t, "hello", events[0].Data)
+	require.Len(t, errs, 1)
+	require.ErrorIs(t, errs[0], rest.ErrStreamIdleTimeout)
+	assert.Equal(t, int32(3), connections.Load())
+}
+
+func TestEvents_ContextCanceled(t *testing.T) {
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", rest.MIMETextEventStream)
+		fmt.Fprint(w, "data: hello\n\n")
+	}))
+
+	client := &rest.Client{BaseURL: srv.URL}
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	var lastErr error
+	for _, err := range client.Events(ctx, "/events") {
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		cancel()
+	}
+
+	require.ErrorIs(t, lastErr, context.Canceled)
+}
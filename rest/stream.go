@@ -0,0 +1,241 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"sync"
+)
+
+// Stream issues a GET HTTP verb to the specified URL and decodes the response body
+// incrementally as newline-delimited JSON (application/x-ndjson or JSON Lines),
+// yielding one (T, error) pair per document.
+//
+// The body is read as the caller iterates, so arbitrarily large responses are never
+// buffered in memory. Breaking out of the loop closes the underlying body, and
+// cancelling ctx stops reading. A request, status or decoding error is yielded once
+// and ends the iteration.
+//
+// Example usage:
+//
+//	for event, err := range rest.Stream[Event](ctx, client, "/events") {
+//	    if err != nil {
+//	        return err
+//	    }
+//	    // ...
+//	}
+func Stream[T any](ctx context.Context, client *Client, url string, headers ...http.Header) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var dflt T
+
+		response, err := client.stream(ctx, http.MethodGet, url, nil, ndjsonAccept, headers...)
+		if err != nil {
+			yield(dflt, err)
+			return
+		}
+		defer func(body io.ReadCloser) {
+			_ = body.Close()
+		}(response.Body)
+
+		decoder := json.NewDecoder(response.Body)
+		for {
+			if err = ctx.Err(); err != nil {
+				yield(dflt, err)
+				return
+			}
+
+			var item T
+			if err = decoder.Decode(&item); err != nil {
+				if !errors.Is(err, io.EOF) {
+					yield(dflt, streamErr(ctx, err))
+				}
+				return
+			}
+
+			if !yield(item, nil) {
+				return
+			}
+		}
+	}
+}
+
+// StreamArray issues a GET HTTP verb to the specified URL and decodes the elements
+// of a top-level JSON array incrementally, yielding one (T, error) pair per element.
+//
+// It follows the same body and cancellation rules as Stream.
+func StreamArray[T any](
+	ctx context.Context,
+	client *Client,
+	url string,
+	headers ...http.Header,
+) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var dflt T
+
+		response, err := client.stream(ctx, http.MethodGet, url, nil, MIMEApplicationJSON, headers...)
+		if err != nil {
+			yield(dflt, err)
+			return
+		}
+		defer func(body io.ReadCloser) {
+			_ = body.Close()
+		}(response.Body)
+
+		decoder := json.NewDecoder(response.Body)
+		token, err := decoder.Token()
+		if err != nil {
+			yield(dflt, streamErr(ctx, err))
+			return
+		}
+		if delim, ok := token.(json.Delim); !ok || delim != '[' {
+			yield(dflt, fmt.Errorf("stream fail, expected a JSON array, got: %v", token))
+			return
+		}
+
+		for decoder.More() {
+			if err = ctx.Err(); err != nil {
+				yield(dflt, err)
+				return
+			}
+
+			var item T
+			if err = decoder.Decode(&item); err != nil {
+				yield(dflt, streamErr(ctx, err))
+				return
+			}
+
+			if !yield(item, nil) {
+				return
+			}
+		}
+
+		if _, err = decoder.Token(); err != nil {
+			yield(dflt, streamErr(ctx, err))
+		}
+	}
+}
+
+// ndjsonAccept is the Accept header value sent by Stream.
+const ndjsonAccept = MIMEApplicationNDJSON + ", " + MIMEApplicationJSONLines + ", " + MIMEApplicationJSON
+
+// stream issues a request and returns the response with its body unread, for callers
+// that decode it incrementally. The body is already decompressed when gzip applies.
+// Non-2xx responses are read in full and returned as a *StatusError.
+//
+// The caller is responsible for closing the response body.
+func (r *Client) stream(
+	ctx context.Context,
+	verb string,
+	apiURL string,
+	body any,
+	accept string,
+	headers ...http.Header,
+) (*http.Response, error) {
+	options := optionsFromContext(ctx)
+	timeout := effectiveTimeout(ctx, options.timeout)
+	ctx, cancelCtx := applyOptions(ctx, options, apiURL)
+
+	// The bulkhead slot and the endpoint are busy until the stream is closed
+	slot, err := r.Bulkhead.acquire(ctx)
+	if err != nil {
+		cancelCtx()
+		return nil, err
+	}
+	endpoint := r.pickEndpoint()
+	cancel := sync.OnceFunc(func() {
+		cancelCtx()
+		endpoint.release()
+		slot.free()
+	})
+
+	apiURL, err = r.resolveURL(endpoint.base(r), apiURL, options)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	httpClient, request, err := r.prepareRequest(endpoint.withContext(ctx), verb, apiURL, body, nil, headers...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if !hasHeader(CanonicalAcceptHeader, headers...) {
+		request.Header.Set(CanonicalAcceptHeader, accept)
+	}
+
+	httpResponse, err := httpClient.Do(request)
+	endpoint.observe(ctx, httpResponse, err)
+	if err != nil {
+		err = requestTimeoutErr(ctx, timeout, err)
+		cancel()
+		return nil, err
+	}
+
+	respReader, err := r.setRespReader(request, httpResponse)
+	if err != nil {
+		_ = httpResponse.Body.Close()
+		cancel()
+		return nil, err
+	}
+
+	if httpResponse.StatusCode < http.StatusOK || httpResponse.StatusCode >= http.StatusMultipleChoices {
+		defer cancel()
+		defer func(Body io.ReadCloser) {
+			_ = Body.Close()
+		}(httpResponse.Body)
+
+		respBody, rErr := io.ReadAll(respReader)
+		if rErr != nil {
+			return nil, rErr
+		}
+
+		return nil, statusError(NewResponse(httpResponse, respBody))
+	}
+
+	httpResponse.Body = &streamBody{
+		Reader: respReader,
+		body:   httpResponse.Body,
+		cancel: cancel,
+	}
+
+	return httpResponse, nil
+}
+
+// streamBody reads the decoded response body and releases the request context on Close.
+type streamBody struct {
+	io.Reader
+	body   io.Closer
+	cancel context.CancelFunc
+}
+
+// Close closes the underlying response body and releases the request context.
+func (r *streamBody) Close() error {
+	defer r.cancel()
+	return r.body.Close()
+}
+
+// streamErr prefers the context error over the read error it caused, so that
+// callers can match context.Canceled or context.DeadlineExceeded.
+func streamErr(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	return err
+}
+
+// hasHeader reports whether any of the given header sets contains the key.
+func hasHeader(key string, headers ...http.Header) bool {
+	for _, header := range headers {
+		if header.Get(key) != "" {
+			return true
+		}
+	}
+
+	return false
+}
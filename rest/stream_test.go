@@ -0,0 +1,147 @@
+package rest_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arielsrv/go-restclient/rest"
+)
+
+func TestStream_NDJSON(t *testing.T) {
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.Header.Get("Accept"), rest.MIMEApplicationNDJSON)
+
+		w.Header().Set("Content-Type", rest.MIMEApplicationNDJSON)
+		for i, name := range userList {
+			fmt.Fprintf(w, "{\"id\":%d,\"name\":%q}\n", i+1, name)
+		}
+	}))
+
+	client := &rest.Client{BaseURL: srv.URL}
+
+	var got []User
+	for user, err := range rest.Stream[User](t.Context(), client, "/users") {
+		require.NoError(t, err)
+		got = append(got, user)
+	}
+
+	require.Len(t, got, len(userList))
+	assert.Equal(t, "Bob", got[1].Name)
+	assert.Equal(t, 3, got[2].ID)
+}
+
+func TestStream_Array(t *testing.T) {
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", rest.MIMEApplicationJSON)
+		fmt.Fprint(w, `[{"id":1,"name":"Alice"}, {"id":2,"name":"Bob"}]`)
+	}))
+
+	client := &rest.Client{BaseURL: srv.URL}
+
+	var got []User
+	for user, err := range rest.StreamArray[User](t.Context(), client, "/users") {
+		require.NoError(t, err)
+		got = append(got, user)
+	}
+
+	require.Len(t, got, 2)
+	assert.Equal(t, "Alice", got[0].Name)
+}
+
+func TestStream_Array_NotArray(t *testing.T) {
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"id":1}`)
+	}))
+
+	client := &rest.Client{BaseURL: srv.URL}
+
+	for _, err := range rest.StreamArray[User](t.Context(), client, "/users") {
+		require.Error(t, err)
+	}
+}
+
+func TestStream_StatusErr(t *testing.T) {
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "not found")
+	}))
+
+	client := &rest.Client{BaseURL: srv.URL}
+
+	calls := 0
+	for _, err := range rest.Stream[User](t.Context(), client, "/users") {
+		calls++
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404")
+	}
+	assert.Equal(t, 1, calls)
+}
+
+func TestStream_BreakClosesBody(t *testing.T) {
+	closed := make(chan struct{})
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", rest.MIMEApplicationNDJSON)
+		for i := 0; ; i++ {
+			if _, err := fmt.Fprintf(w, "{\"id\":%d}\n", i); err != nil {
+				close(closed)
+				return
+			}
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				close(closed)
+				return
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}))
+
+	client := &rest.Client{BaseURL: srv.URL, DisableTimeout: true}
+
+	count := 0
+	for _, err := range rest.Stream[User](t.Context(), client, "/users") {
+		require.NoError(t, err)
+		count++
+		if count == 3 {
+			break
+		}
+	}
+
+	assert.Equal(t, 3, count)
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server should observe the connection being closed")
+	}
+}
+
+func TestStream_ContextCanceled(t *testing.T) {
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", rest.MIMEApplicationNDJSON)
+		fmt.Fprint(w, "{\"id\":1}\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+
+	client := &rest.Client{BaseURL: srv.URL, DisableTimeout: true}
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	var lastErr error
+	for user, err := range rest.Stream[User](ctx, client, "/users") {
+		if err != nil {
+			lastErr = err
+			break
+		}
+		assert.Equal(t, 1, user.ID)
+		cancel()
+	}
+
+	require.ErrorIs(t, lastErr, context.Canceled)
+}
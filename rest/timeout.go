@@ -0,0 +1,218 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// TimeoutPhase names the phase of a request whose timeout expired.
+type TimeoutPhase string
+
+const (
+	// TimeoutPhaseConnect is the establishment of the connection (see WithConnectTimeout).
+	TimeoutPhaseConnect TimeoutPhase = "connect"
+	// TimeoutPhaseResponseHeaders is the wait for the response headers (see WithHeaderTimeout).
+	TimeoutPhaseResponseHeaders TimeoutPhase = "response headers"
+	// TimeoutPhaseRequest is the whole request, including reading the body (see WithTimeout).
+	TimeoutPhaseRequest TimeoutPhase = "request"
+	// TimeoutPhaseQueue is the wait for a free slot of the bulkhead (see Bulkhead.QueueTimeout).
+	TimeoutPhaseQueue TimeoutPhase = "bulkhead queue"
+)
+
+// errResponseHeaderTimeout is the cause of a request cancelled while awaiting its response headers.
+var errResponseHeaderTimeout = fmt.Errorf("timeout awaiting response headers: %w", context.DeadlineExceeded)
+
+// TimeoutError is reported in Response.Err when a request phase exceeds its timeout.
+// It matches context.DeadlineExceeded with errors.Is and implements net.Error.
+//
+// Example usage:
+//
+//	var timeoutErr *rest.TimeoutError
+//	if errors.As(response.Err, &timeoutErr) {
+//	    log.Printf("%s timed out after %s", timeoutErr.Phase, timeoutErr.After)
+//	}
+type TimeoutError struct {
+	Err   error
+	Phase TimeoutPhase
+	After time.Duration
+}
+
+// Error returns the expired phase, its effective timeout and the underlying error.
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s timeout after %s: %v", e.Phase, e.After, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout reports true, so TimeoutError implements net.Error.
+func (e *TimeoutError) Timeout() bool {
+	return true
+}
+
+// Temporary reports true, so TimeoutError implements net.Error.
+func (e *TimeoutError) Temporary() bool {
+	return true
+}
+
+// connectTimeoutKey is the context key for the effective connect timeout of a request.
+type connectTimeoutKey struct{}
+
+// timeoutTransport enforces the connect and response header timeouts per request, so
+// they can be overridden per call with WithConnectTimeout and WithHeaderTimeout.
+type timeoutTransport struct {
+	Transport      http.RoundTripper
+	connectTimeout time.Duration
+	headerTimeout  time.Duration
+}
+
+// RoundTrip bounds the wait for the response headers and hands the connect timeout
+// to the dialer through the request context.
+func (r *timeoutTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	ctx := request.Context()
+	options := activeOptions(ctx)
+
+	connectTimeout := r.connectTimeout
+	if options.connectTimeout > 0 {
+		connectTimeout = options.connectTimeout
+	}
+	ctx = context.WithValue(ctx, connectTimeoutKey{}, connectTimeout)
+
+	// Events bound the wait for the response headers with StreamIdleTimeout instead
+	headerTimeout := r.headerTimeout
+	if options.eventStream {
+		headerTimeout = 0
+	}
+	if options.headerTimeout > 0 {
+		headerTimeout = options.headerTimeout
+	}
+
+	// A sooner context deadline governs the wait and expires as the request timeout
+	if headerTimeout <= 0 || effectiveTimeout(ctx, headerTimeout) < headerTimeout {
+		return r.Transport.RoundTrip(request.WithContext(ctx))
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	timer := newHeaderTimer(headerTimeout, func() {
+		cancel(errResponseHeaderTimeout)
+	})
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			timer.start()
+		},
+	})
+
+	response, err := r.Transport.RoundTrip(request.WithContext(ctx))
+	timer.stop()
+
+	if errors.Is(context.Cause(ctx), errResponseHeaderTimeout) {
+		if response != nil {
+			_ = response.Body.Close()
+		}
+		cancel(nil)
+		return nil, &TimeoutError{
+			Err:   errResponseHeaderTimeout,
+			Phase: TimeoutPhaseResponseHeaders,
+			After: headerTimeout,
+		}
+	}
+
+	if err != nil {
+		cancel(nil)
+		return nil, err
+	}
+
+	response.Body = &cancelBody{
+		ReadCloser: response.Body,
+		cancel:     func() { cancel(nil) },
+	}
+
+	return response, nil
+}
+
+// headerTimer bounds the wait for the response headers. Like http.Transport's
+// ResponseHeaderTimeout, it starts once the request has been fully written, so the
+// time spent waiting for a pooled connection is not counted.
+type headerTimer struct {
+	timer   *time.Timer
+	timeout time.Duration
+	mtx     sync.Mutex
+	stopped bool
+}
+
+// newHeaderTimer returns a timer that calls expire once started and not stopped in time.
+func newHeaderTimer(timeout time.Duration, expire func()) *headerTimer {
+	timer := time.AfterFunc(timeout, expire)
+	timer.Stop()
+
+	return &headerTimer{timer: timer, timeout: timeout}
+}
+
+// start starts the timer, unless the response has already been received.
+func (r *headerTimer) start() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if !r.stopped {
+		r.timer.Reset(r.timeout)
+	}
+}
+
+// stop stops the timer for good.
+func (r *headerTimer) stop() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.stopped = true
+	r.timer.Stop()
+}
+
+// cancelBody releases the context of a request once its response body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the response body and releases the request context.
+func (r *cancelBody) Close() error {
+	defer r.cancel()
+	return r.ReadCloser.Close()
+}
+
+// effectiveTimeout returns the smaller of timeout and the time left until the deadline
+// of ctx. A zero timeout means no timeout.
+func effectiveTimeout(ctx context.Context, timeout time.Duration) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return timeout
+	}
+
+	if remaining := time.Until(deadline); timeout <= 0 || remaining < timeout {
+		return max(remaining, time.Nanosecond)
+	}
+
+	return timeout
+}
+
+// requestTimeoutErr reports err as a request TimeoutError when ctx expired before the
+// request completed. Errors already attributed to a phase are returned as is.
+func requestTimeoutErr(ctx context.Context, timeout time.Duration, err error) error {
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+
+	return &TimeoutError{
+		Err:   err,
+		Phase: TimeoutPhaseRequest,
+		After: timeout,
+	}
+}
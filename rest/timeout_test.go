@@ -0,0 +1,91 @@
+package rest_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arielsrv/go-restclient/rest"
+)
+
+func TestRequest_HeaderTimeout_Override(t *testing.T) {
+	client := &rest.Client{BaseURL: server.URL, Timeout: 10 * time.Millisecond}
+
+	response := client.Get(server.URL + "/slow/user")
+	require.Error(t, response.Err)
+
+	response = client.R().HeaderTimeout(time.Second).Get(t.Context(), "/slow/user")
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestRequest_HeaderTimeout(t *testing.T) {
+	client := &rest.Client{BaseURL: server.URL, Timeout: time.Second}
+
+	response := client.R().HeaderTimeout(10*time.Millisecond).Get(t.Context(), "/slow/user")
+	require.Error(t, response.Err)
+	require.ErrorIs(t, response.Err, context.DeadlineExceeded)
+	assert.Contains(t, response.Err.Error(), "timeout awaiting response headers")
+
+	var timeoutErr *rest.TimeoutError
+	require.ErrorAs(t, response.Err, &timeoutErr)
+	assert.Equal(t, rest.TimeoutPhaseResponseHeaders, timeoutErr.Phase)
+	assert.Equal(t, 10*time.Millisecond, timeoutErr.After)
+}
+
+func TestRequest_Timeout_ContextDeadline(t *testing.T) {
+	client := &rest.Client{BaseURL: server.URL, Timeout: time.Second}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+
+	response := client.R().Timeout(time.Second).HeaderTimeout(time.Second).Get(ctx, "/slow/user")
+	require.ErrorIs(t, response.Err, context.DeadlineExceeded)
+
+	var timeoutErr *rest.TimeoutError
+	require.ErrorAs(t, response.Err, &timeoutErr)
+	assert.Equal(t, rest.TimeoutPhaseRequest, timeoutErr.Phase)
+	assert.LessOrEqual(t, timeoutErr.After, 10*time.Millisecond)
+}
+
+func TestRequest_Timeout_Body(t *testing.T) {
+	srv := newServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+
+	client := &rest.Client{BaseURL: srv.URL}
+
+	response := client.R().Timeout(50*time.Millisecond).Get(t.Context(), "/")
+	require.ErrorIs(t, response.Err, context.DeadlineExceeded)
+
+	var timeoutErr *rest.TimeoutError
+	require.ErrorAs(t, response.Err, &timeoutErr)
+	assert.Equal(t, rest.TimeoutPhaseRequest, timeoutErr.Phase)
+	assert.Equal(t, 50*time.Millisecond, timeoutErr.After)
+}
+
+func TestRequest_ConnectTimeout(t *testing.T) {
+	client := &rest.Client{
+		BaseURL:        server.URL,
+		ConnectTimeout: time.Second,
+		CustomPool:     &rest.CustomPool{Transport: &http.Transport{}},
+	}
+
+	response := client.R().ConnectTimeout(time.Nanosecond).Get(t.Context(), "/user")
+	require.Error(t, response.Err)
+
+	var timeoutErr *rest.TimeoutError
+	require.ErrorAs(t, response.Err, &timeoutErr)
+	assert.Equal(t, rest.TimeoutPhaseConnect, timeoutErr.Phase)
+	assert.Contains(t, response.Err.Error(), "connect timeout after 1ns")
+}
@@ -0,0 +1,348 @@
+package rest
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TLSConfig configures the TLS connections of a Client: client certificates for mutual
+// TLS, extra root CAs, the minimum TLS version, the server name sent with SNI and the
+// public keys pinned for the server.
+//
+// Client certificates loaded from CertFile and KeyFile are reloaded when the files change
+// on disk, so rotated certificates are used by new connections without recreating the
+// client. When the new files cannot be loaded, e.g. while only one of them has been
+// replaced, the previous certificate keeps being used.
+//
+// Example usage:
+//
+//	client := &rest.Client{
+//	    BaseURL: "https://internal.example.com",
+//	    TLS: &rest.TLSConfig{
+//	        CertFile:    "/etc/certs/client.crt",
+//	        KeyFile:     "/etc/certs/client.key",
+//	        RootCAFiles: []string{"/etc/certs/internal-ca.pem"},
+//	        MinVersion:  tls.VersionTLS13,
+//	    },
+//	}
+type TLSConfig struct {
+	// CertFile and KeyFile are the PEM files of the client certificate and its key.
+	// They take precedence over Certificates.
+	CertFile string
+	KeyFile  string
+	// ServerName overrides the server name sent with SNI and verified against the
+	// server certificate.
+	ServerName string
+	// Certificates are client certificates, for keys not stored in files.
+	Certificates []tls.Certificate
+	// RootCAFiles are PEM bundles of root CAs trusted on top of the system ones.
+	RootCAFiles []string
+	// RootCAs are PEM encoded root CAs trusted on top of the system ones.
+	RootCAs [][]byte
+	// Pins are the SPKI pins accepted for the server: base64 SHA-256 hashes of the public
+	// key of the leaf certificate or an intermediate, optionally prefixed by "sha256/", as
+	// returned by SPKIPin. List backup pins for the next key, so rotating it does not
+	// break the client. Connections whose verified chain matches no pin fail with a
+	// *PinError. Without a verified chain, e.g. with InsecureSkipVerify, only the leaf
+	// certificate is matched.
+	Pins []string
+	// PinReportOnly logs pin mismatches with log/slog instead of failing the connection.
+	PinReportOnly bool
+	// MinVersion is the minimum TLS version, e.g. tls.VersionTLS13. TLS 1.2 by default.
+	MinVersion uint16
+}
+
+// PinError is the error of a connection whose certificate chain matches none of the
+// configured pins.
+//
+// Example usage:
+//
+//	var pinErr *rest.PinError
+//	if errors.As(response.Err, &pinErr) {
+//	    alert("certificate pin mismatch for %s: %v", pinErr.Host, pinErr.Chain)
+//	}
+type PinError struct {
+	// Host is the server name of the connection, or the dialed host for IP addresses,
+	// which send no server name.
+	Host string
+	// Chain are the SPKI pins of the certificates presented by the server, leaf first.
+	Chain []string
+}
+
+// Error returns the host and the pins of its certificate chain.
+func (e *PinError) Error() string {
+	return fmt.Sprintf("tls: certificate pin mismatch for %s, chain pins: %s", e.Host, strings.Join(e.Chain, ", "))
+}
+
+// SPKIPin returns the pin of the certificate: "sha256/" followed by the base64 SHA-256
+// hash of its SubjectPublicKeyInfo.
+func SPKIPin(certificate *x509.Certificate) string {
+	sum := sha256.Sum256(certificate.RawSubjectPublicKeyInfo)
+	return "sha256/" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// verifyPins returns the VerifyConnection function checking the chain of a connection
+// to the given host against the pins.
+func (r *TLSConfig) verifyPins() (func(host string) func(tls.ConnectionState) error, error) {
+	pins := make(map[string]bool, len(r.Pins))
+	for _, pin := range r.Pins {
+		sum, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pin, "sha256/"))
+		if err != nil || len(sum) != sha256.Size {
+			return nil, fmt.Errorf("tls: invalid pin: %s", pin)
+		}
+		pins[base64.StdEncoding.EncodeToString(sum)] = true
+	}
+
+	return func(host string) func(tls.ConnectionState) error {
+		return func(state tls.ConnectionState) error {
+			// Unverified intermediates may be sent by anyone, only the leaf is trusted
+			chains := state.VerifiedChains
+			if len(chains) == 0 && len(state.PeerCertificates) > 0 {
+				chains = [][]*x509.Certificate{state.PeerCertificates[:1]}
+			}
+
+			var chainPins []string
+			for _, chain := range chains {
+				for _, certificate := range chain {
+					pin := SPKIPin(certificate)
+					if pins[strings.TrimPrefix(pin, "sha256/")] {
+						return nil
+					}
+					if !slices.Contains(chainPins, pin) {
+						chainPins = append(chainPins, pin)
+					}
+				}
+			}
+
+			pinErr := &PinError{Host: cmp.Or(state.ServerName, host), Chain: chainPins}
+			if r.PinReportOnly {
+				slog.Warn("tls: certificate pin mismatch", "host", pinErr.Host, "chain", pinErr.Chain)
+				return nil
+			}
+
+			return pinErr
+		}
+	}, nil
+}
+
+// dialTLSContext returns the DialTLSContext function of a transport with pins. It dials
+// with the transport dialer and verifies the pins knowing the dialed host, so that pin
+// mismatches of IP addresses name their host.
+func (r *TLSConfig) dialTLSContext(
+	transport *http.Transport,
+) (func(context.Context, string, string) (net.Conn, error), error) {
+	verify, err := r.verifyPins()
+	if err != nil {
+		return nil, err
+	}
+
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, dErr := dial(ctx, network, address)
+		if dErr != nil {
+			return nil, dErr
+		}
+
+		host, _, sErr := net.SplitHostPort(address)
+		if sErr != nil {
+			host = address
+		}
+
+		config := transport.TLSClientConfig.Clone()
+		if config == nil {
+			config = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		if config.ServerName == "" {
+			config.ServerName = host
+		}
+		config.VerifyConnection = verify(host)
+
+		tlsConn := tls.Client(conn, config)
+		if hErr := tlsConn.HandshakeContext(ctx); hErr != nil {
+			_ = conn.Close()
+			return nil, hErr
+		}
+
+		return tlsConn, nil
+	}, nil
+}
+
+// config returns the TLS configuration applied on top of base, which may be nil.
+func (r *TLSConfig) config(base *tls.Config) (*tls.Config, error) {
+	config := base.Clone()
+	if config == nil {
+		config = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	if r.MinVersion != 0 {
+		config.MinVersion = r.MinVersion
+	}
+
+	if r.ServerName != "" {
+		config.ServerName = r.ServerName
+	}
+
+	if len(r.RootCAFiles) > 0 || len(r.RootCAs) > 0 {
+		pool, err := r.rootCAs(config.RootCAs)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+
+	if len(r.Pins) > 0 {
+		verify, err := r.verifyPins()
+		if err != nil {
+			return nil, err
+		}
+		config.VerifyConnection = verify("")
+	}
+
+	switch {
+	case r.CertFile != "" || r.KeyFile != "":
+		if r.CertFile == "" || r.KeyFile == "" {
+			return nil, errors.New("tls: both CertFile and KeyFile must be set")
+		}
+
+		reloader := &certReloader{certFile: r.CertFile, keyFile: r.KeyFile}
+		if _, err := reloader.certificate(); err != nil {
+			return nil, err
+		}
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return reloader.certificate()
+		}
+	case len(r.Certificates) > 0:
+		config.Certificates = r.Certificates
+	}
+
+	return config, nil
+}
+
+// rootCAs returns the given pool, or the system one, with the extra root CAs.
+func (r *TLSConfig) rootCAs(base *x509.CertPool) (*x509.CertPool, error) {
+	pool := base
+	if pool == nil {
+		var err error
+		if pool, err = x509.SystemCertPool(); err != nil {
+			pool = x509.NewCertPool()
+		}
+	} else {
+		pool = pool.Clone()
+	}
+
+	for _, file := range r.RootCAFiles {
+		bundle, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("tls: reading root CAs: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("tls: no certificates found in %s", file)
+		}
+	}
+
+	for _, bundle := range r.RootCAs {
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, errors.New("tls: no certificates found in RootCAs")
+		}
+	}
+
+	return pool, nil
+}
+
+// certReloader loads a client certificate from PEM files and reloads it when the files
+// change, checking their modification time on every handshake.
+type certReloader struct {
+	modTime  time.Time
+	current  *tls.Certificate
+	certFile string
+	keyFile  string
+	mtx      sync.Mutex
+}
+
+// certificate returns the current certificate, reloading it if the files have changed.
+// A certificate that fails to reload is ignored while a previous one is available.
+func (r *certReloader) certificate() (*tls.Certificate, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	modTime, err := r.lastModified()
+	if err != nil && r.current == nil {
+		return nil, fmt.Errorf("tls: loading client certificate: %w", err)
+	}
+
+	if r.current != nil && (err != nil || modTime.Equal(r.modTime)) {
+		return r.current, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		if r.current != nil {
+			return r.current, nil
+		}
+		return nil, fmt.Errorf("tls: loading client certificate: %w", err)
+	}
+	r.current, r.modTime = &certificate, modTime
+
+	return r.current, nil
+}
+
+// lastModified returns the latest modification time of the certificate and key files.
+func (r *certReloader) lastModified() (time.Time, error) {
+	var modTime time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+
+	return modTime, nil
+}
+
+// errTransport fails every request with the error found while setting up the transport.
+type errTransport struct {
+	err error
+}
+
+// RoundTrip returns the setup error.
+func (r *errTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	closeBody(request)
+	return nil, &setupError{err: r.err}
+}
+
+// setupError is the error of a request sent through an errTransport, so that it is not
+// retried.
+type setupError struct {
+	err error
+}
+
+// Error returns the setup error message.
+func (e *setupError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the setup error.
+func (e *setupError) Unwrap() error {
+	return e.err
+}
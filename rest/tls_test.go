@@ -0,0 +1,349 @@
+package rest_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arielsrv/go-restclient/rest"
+)
+
+// testCA is a certificate authority issuing test certificates.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns the PEM certificate and key of a server or client certificate.
+func (r *testCA) issue(t *testing.T, commonName string, usage x509.ExtKeyUsage, dnsNames ...string) ([]byte, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		DNSNames:     dnsNames,
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, r.cert, &key.PublicKey, r.key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// echoClientCert answers with the common name of the client certificate, if any, and
+// closes every connection, so each request performs a new handshake.
+func echoClientCert(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Connection", "close")
+	w.Header().Set("X-Server-Name", r.TLS.ServerName)
+	if len(r.TLS.PeerCertificates) > 0 {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}
+}
+
+// requireClientCert configures a TLS server requiring a client certificate issued by the
+// CA, unless changed by config.
+func requireClientCert(t *testing.T, ca *testCA, config func(*tls.Config)) func(*httptest.Server) {
+	t.Helper()
+
+	certPEM, keyPEM := ca.issue(t, "server", x509.ExtKeyUsageServerAuth, "api.internal")
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+
+	return func(srv *httptest.Server) {
+		srv.TLS = &tls.Config{
+			Certificates: []tls.Certificate{certificate},
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    clientCAs,
+			MinVersion:   tls.VersionTLS12,
+		}
+		if config != nil {
+			config(srv.TLS)
+		}
+	}
+}
+
+// writeCert writes the PEM certificate and key with the given modification time.
+func writeCert(t *testing.T, certFile, keyFile string, certPEM, keyPEM []byte, modTime time.Time) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+	require.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+}
+
+func TestTLS_MutualTLS_Reload(t *testing.T) {
+	ca := newTestCA(t)
+	srv := newServer(t, http.HandlerFunc(echoClientCert), requireClientCert(t, ca, nil))
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, ca.pem, 0o600))
+
+	certPEM, keyPEM := ca.issue(t, "client-1", x509.ExtKeyUsageClientAuth)
+	writeCert(t, certFile, keyFile, certPEM, keyPEM, time.Now().Add(-time.Minute))
+
+	client := &rest.Client{
+		BaseURL: srv.URL,
+		TLS: &rest.TLSConfig{
+			CertFile:    certFile,
+			KeyFile:     keyFile,
+			RootCAFiles: []string{caFile},
+			MinVersion:  tls.VersionTLS12,
+		},
+	}
+
+	response := client.GetWithContext(t.Context(), "/")
+	require.NoError(t, response.Err)
+	assert.Equal(t, "client-1", response.String())
+
+	// A half-rotated pair keeps the previous certificate
+	certPEM, keyPEM = ca.issue(t, "client-2", x509.ExtKeyUsageClientAuth)
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+
+	response = client.GetWithContext(t.Context(), "/")
+	require.NoError(t, response.Err)
+	assert.Equal(t, "client-1", response.String())
+
+	writeCert(t, certFile, keyFile, certPEM, keyPEM, time.Now())
+
+	response = client.GetWithContext(t.Context(), "/")
+	require.NoError(t, response.Err)
+	assert.Equal(t, "client-2", response.String())
+}
+
+func TestTLS_Certificates_ServerName(t *testing.T) {
+	ca := newTestCA(t)
+	srv := newServer(t, http.HandlerFunc(echoClientCert), requireClientCert(t, ca, nil))
+
+	certPEM, keyPEM := ca.issue(t, "client", x509.ExtKeyUsageClientAuth)
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+
+	client := &rest.Client{
+		BaseURL: srv.URL,
+		TLS: &rest.TLSConfig{
+			Certificates: []tls.Certificate{certificate},
+			RootCAs:      [][]byte{ca.pem},
+			ServerName:   "api.internal",
+		},
+	}
+
+	response := client.GetWithContext(t.Context(), "/")
+	require.NoError(t, response.Err)
+	assert.Equal(t, "client", response.String())
+	assert.Equal(t, "api.internal", response.Header.Get("X-Server-Name"))
+}
+
+func TestTLS_MinVersion(t *testing.T) {
+	ca := newTestCA(t)
+	srv := newServer(t, http.HandlerFunc(echoClientCert), requireClientCert(t, ca, func(config *tls.Config) {
+		config.MaxVersion = tls.VersionTLS12
+	}))
+
+	certPEM, keyPEM := ca.issue(t, "client", x509.ExtKeyUsageClientAuth)
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+
+	client := &rest.Client{
+		BaseURL:    srv.URL,
+		CustomPool: &rest.CustomPool{MaxIdleConnsPerHost: 10},
+		TLS: &rest.TLSConfig{
+			Certificates: []tls.Certificate{certificate},
+			RootCAs:      [][]byte{ca.pem},
+			MinVersion:   tls.VersionTLS13,
+		},
+	}
+
+	response := client.GetWithContext(t.Context(), "/")
+	require.Error(t, response.Err)
+	assert.Contains(t, response.Err.Error(), "protocol version")
+}
+
+func TestTLS_Err(t *testing.T) {
+	client := &rest.Client{
+		BaseURL: server.URL,
+		TLS:     &rest.TLSConfig{CertFile: "missing.crt", KeyFile: "missing.key"},
+	}
+
+	response := client.GetWithContext(t.Context(), "/user")
+	require.ErrorIs(t, response.Err, os.ErrNotExist)
+
+	client = &rest.Client{
+		BaseURL:    server.URL,
+		CustomPool: &rest.CustomPool{Transport: http.NewFileTransport(http.Dir("."))},
+		TLS:        &rest.TLSConfig{MinVersion: tls.VersionTLS13},
+	}
+
+	response = client.GetWithContext(t.Context(), "/user")
+	require.Error(t, response.Err)
+}
+
+func TestTLS_Pins(t *testing.T) {
+	ca := newTestCA(t)
+	srv := newServer(t, http.HandlerFunc(echoClientCert), requireClientCert(t, ca, func(config *tls.Config) {
+		config.ClientAuth = tls.NoClientCert
+	}))
+
+	_, backupKey := ca.issue(t, "backup", x509.ExtKeyUsageServerAuth)
+	block, _ := pem.Decode(backupKey)
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	require.NoError(t, err)
+	spki, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	backupPin := rest.SPKIPin(&x509.Certificate{RawSubjectPublicKeyInfo: spki})
+
+	tests := []struct {
+		name string
+		pins []string
+	}{
+		{name: "leaf", pins: []string{rest.SPKIPin(srv.Certificate())}},
+		{name: "issuer", pins: []string{rest.SPKIPin(ca.cert)}},
+		{name: "backup", pins: []string{backupPin, strings.TrimPrefix(rest.SPKIPin(srv.Certificate()), "sha256/")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &rest.Client{
+				BaseURL: srv.URL,
+				TLS:     &rest.TLSConfig{RootCAs: [][]byte{ca.pem}, Pins: tt.pins},
+			}
+
+			response := client.GetWithContext(t.Context(), "/")
+			require.NoError(t, response.Err)
+			assert.Equal(t, http.StatusOK, response.StatusCode)
+		})
+	}
+}
+
+func TestTLS_Pins_Mismatch(t *testing.T) {
+	ca := newTestCA(t)
+	srv := newServer(t, http.HandlerFunc(echoClientCert), requireClientCert(t, ca, func(config *tls.Config) {
+		config.ClientAuth = tls.NoClientCert
+	}))
+	pin := "sha256/" + base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+
+	client := &rest.Client{
+		BaseURL: srv.URL,
+		TLS:     &rest.TLSConfig{RootCAs: [][]byte{ca.pem}, Pins: []string{pin}},
+	}
+
+	response := client.GetWithContext(t.Context(), "/")
+	var pinErr *rest.PinError
+	require.ErrorAs(t, response.Err, &pinErr)
+	assert.Equal(t, []string{rest.SPKIPin(srv.Certificate()), rest.SPKIPin(ca.cert)}, pinErr.Chain)
+	// IP addresses send no server name, the dialed host is reported
+	assert.Equal(t, "127.0.0.1", pinErr.Host)
+
+	client = &rest.Client{
+		BaseURL: srv.URL,
+		TLS:     &rest.TLSConfig{RootCAs: [][]byte{ca.pem}, Pins: []string{pin}, PinReportOnly: true},
+	}
+
+	response = client.GetWithContext(t.Context(), "/")
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	client = &rest.Client{
+		BaseURL: srv.URL,
+		TLS:     &rest.TLSConfig{Pins: []string{"sha256/invalid"}},
+	}
+
+	response = client.GetWithContext(t.Context(), "/")
+	require.ErrorContains(t, response.Err, "invalid pin")
+}
+
+func TestTLS_Pins_Unverified(t *testing.T) {
+	ca := newTestCA(t)
+	srv := newServer(t, http.HandlerFunc(echoClientCert), requireClientCert(t, ca, func(config *tls.Config) {
+		config.ClientAuth = tls.NoClientCert
+		config.Certificates[0].Certificate = append(config.Certificates[0].Certificate, ca.cert.Raw)
+	}))
+
+	// Without verification, the intermediates sent by the server are not trusted
+	client := &rest.Client{
+		BaseURL: srv.URL,
+		CustomPool: &rest.CustomPool{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec // trusted by its pin only
+		}},
+		TLS: &rest.TLSConfig{Pins: []string{rest.SPKIPin(ca.cert)}},
+	}
+
+	response := client.GetWithContext(t.Context(), "/")
+	var pinErr *rest.PinError
+	require.ErrorAs(t, response.Err, &pinErr)
+	assert.Equal(t, []string{rest.SPKIPin(srv.Certificate())}, pinErr.Chain)
+
+	client = &rest.Client{
+		BaseURL: srv.URL,
+		CustomPool: &rest.CustomPool{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec // trusted by its pin only
+		}},
+		TLS: &rest.TLSConfig{Pins: []string{rest.SPKIPin(srv.Certificate())}},
+	}
+
+	response = client.GetWithContext(t.Context(), "/")
+	require.NoError(t, response.Err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}